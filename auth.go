@@ -0,0 +1,384 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// authRecvWindow 签名请求的有效时间窗口（毫秒），Binance和Bybit需要
+const authRecvWindow = "5000"
+
+// DefaultAccount 默认账户，凭证读取不带账户后缀的环境变量
+const DefaultAccount = "default"
+
+// Credentials 交易所API凭证
+type Credentials struct {
+	APIKey     string
+	SecretKey  string
+	Passphrase string // OKX、Bitget创建API时设置的密码
+}
+
+// authBaseURLs 各交易所签名接口的根地址，Gate的签名包含 /api/v4 前缀
+var authBaseURLs = map[string]string{
+	"Binance": "https://fapi.binance.com",
+	"OKX":     "https://www.okx.com",
+	"Bybit":   "https://api.bybit.com",
+	"MEXC":    "https://contract.mexc.com",
+	"Bitget":  "https://api.bitget.com",
+	"Gate":    "https://api.gateio.ws/api/v4",
+}
+
+// mexcSpotBaseURL MEXC现货API的根地址，充提接口在现货API中，签名方式与合约API不同
+const mexcSpotBaseURL = "https://api.mexc.com"
+
+// Signer 为请求添加交易所要求的认证信息，timestamp为毫秒时间戳
+type Signer interface {
+	Sign(req *http.Request, body []byte, timestamp int64) error
+}
+
+// newSigner 按交易所创建签名器
+func newSigner(exchange string, credentials Credentials) (Signer, error) {
+	if credentials.APIKey == "" || credentials.SecretKey == "" {
+		return nil, fmt.Errorf("缺少API Key或Secret")
+	}
+
+	switch exchange {
+	case "Binance":
+		return &binanceSigner{credentials}, nil
+	case "OKX":
+		if credentials.Passphrase == "" {
+			return nil, fmt.Errorf("缺少Passphrase")
+		}
+		return &okxSigner{credentials}, nil
+	case "Bybit":
+		return &bybitSigner{credentials}, nil
+	case "MEXC":
+		return &mexcSigner{credentials}, nil
+	case "Bitget":
+		if credentials.Passphrase == "" {
+			return nil, fmt.Errorf("缺少Passphrase")
+		}
+		return &bitgetSigner{credentials}, nil
+	case "Gate":
+		return &gateSigner{credentials}, nil
+	default:
+		return nil, fmt.Errorf("不支持的交易所: %s", exchange)
+	}
+}
+
+func hmacSum(newHash func() hash.Hash, secret string, payload string) []byte {
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// binanceSigner 参数中加入timestamp和recvWindow，对 查询字符串+请求体 做HMAC-SHA256，十六进制签名追加为signature参数
+type binanceSigner struct {
+	credentials Credentials
+}
+
+func (s *binanceSigner) Sign(req *http.Request, body []byte, timestamp int64) error {
+	query := req.URL.RawQuery
+	if query != "" {
+		query += "&"
+	}
+	query += "recvWindow=" + authRecvWindow + "&timestamp=" + strconv.FormatInt(timestamp, 10)
+
+	signature := hex.EncodeToString(hmacSum(sha256.New, s.credentials.SecretKey, query+string(body)))
+	req.URL.RawQuery = query + "&signature=" + signature
+	req.Header.Set("X-MBX-APIKEY", s.credentials.APIKey)
+	return nil
+}
+
+// okxSigner 对 ISO时间 + 方法 + 路径(含查询) + 请求体 做HMAC-SHA256，Base64签名放在请求头
+type okxSigner struct {
+	credentials Credentials
+}
+
+func (s *okxSigner) Sign(req *http.Request, body []byte, timestamp int64) error {
+	isoTime := time.UnixMilli(timestamp).UTC().Format("2006-01-02T15:04:05.000Z")
+	payload := isoTime + req.Method + req.URL.RequestURI() + string(body)
+
+	req.Header.Set("OK-ACCESS-KEY", s.credentials.APIKey)
+	req.Header.Set("OK-ACCESS-SIGN", base64.StdEncoding.EncodeToString(hmacSum(sha256.New, s.credentials.SecretKey, payload)))
+	req.Header.Set("OK-ACCESS-TIMESTAMP", isoTime)
+	req.Header.Set("OK-ACCESS-PASSPHRASE", s.credentials.Passphrase)
+	return nil
+}
+
+// bybitSigner v5：对 时间戳 + API Key + recvWindow + 查询字符串(GET)或请求体(POST) 做HMAC-SHA256，十六进制签名放在请求头
+type bybitSigner struct {
+	credentials Credentials
+}
+
+func (s *bybitSigner) Sign(req *http.Request, body []byte, timestamp int64) error {
+	params := req.URL.RawQuery
+	if req.Method != http.MethodGet {
+		params = string(body)
+	}
+	ts := strconv.FormatInt(timestamp, 10)
+	payload := ts + s.credentials.APIKey + authRecvWindow + params
+
+	req.Header.Set("X-BAPI-API-KEY", s.credentials.APIKey)
+	req.Header.Set("X-BAPI-TIMESTAMP", ts)
+	req.Header.Set("X-BAPI-RECV-WINDOW", authRecvWindow)
+	req.Header.Set("X-BAPI-SIGN", hex.EncodeToString(hmacSum(sha256.New, s.credentials.SecretKey, payload)))
+	return nil
+}
+
+// mexcSigner 合约API：对 API Key + 时间戳 + 参数 做HMAC-SHA256，GET参数按键名排序拼接（AuthClient已排序），POST为JSON请求体
+type mexcSigner struct {
+	credentials Credentials
+}
+
+func (s *mexcSigner) Sign(req *http.Request, body []byte, timestamp int64) error {
+	params := string(body)
+	if req.Method == http.MethodGet {
+		params = req.URL.RawQuery
+	}
+	ts := strconv.FormatInt(timestamp, 10)
+	payload := s.credentials.APIKey + ts + params
+
+	req.Header.Set("ApiKey", s.credentials.APIKey)
+	req.Header.Set("Request-Time", ts)
+	req.Header.Set("Signature", hex.EncodeToString(hmacSum(sha256.New, s.credentials.SecretKey, payload)))
+	return nil
+}
+
+// mexcSpotSigner 现货API v3：与币安相同，参数中加入timestamp和recvWindow，对 查询字符串+请求体 做HMAC-SHA256，
+// 十六进制签名追加为signature参数，API Key放在 X-MEXC-APIKEY 请求头
+type mexcSpotSigner struct {
+	credentials Credentials
+}
+
+func (s *mexcSpotSigner) Sign(req *http.Request, body []byte, timestamp int64) error {
+	signer := binanceSigner{s.credentials}
+	if err := signer.Sign(req, body, timestamp); err != nil {
+		return err
+	}
+	req.Header.Del("X-MBX-APIKEY")
+	req.Header.Set("X-MEXC-APIKEY", s.credentials.APIKey)
+	return nil
+}
+
+// bitgetSigner v2：对 毫秒时间戳 + 方法 + 路径(含?查询) + 请求体 做HMAC-SHA256，Base64签名放在请求头
+type bitgetSigner struct {
+	credentials Credentials
+}
+
+func (s *bitgetSigner) Sign(req *http.Request, body []byte, timestamp int64) error {
+	ts := strconv.FormatInt(timestamp, 10)
+	payload := ts + req.Method + req.URL.RequestURI() + string(body)
+
+	req.Header.Set("ACCESS-KEY", s.credentials.APIKey)
+	req.Header.Set("ACCESS-SIGN", base64.StdEncoding.EncodeToString(hmacSum(sha256.New, s.credentials.SecretKey, payload)))
+	req.Header.Set("ACCESS-TIMESTAMP", ts)
+	req.Header.Set("ACCESS-PASSPHRASE", s.credentials.Passphrase)
+	req.Header.Set("locale", "en-US")
+	return nil
+}
+
+// gateSigner v4：对 方法\n路径\n查询\nSHA512(请求体)\n秒级时间戳 做HMAC-SHA512，十六进制签名放在请求头
+type gateSigner struct {
+	credentials Credentials
+}
+
+func (s *gateSigner) Sign(req *http.Request, body []byte, timestamp int64) error {
+	ts := strconv.FormatInt(timestamp/1000, 10)
+	bodyHash := sha512.Sum512(body)
+	payload := strings.Join([]string{req.Method, req.URL.Path, req.URL.RawQuery, hex.EncodeToString(bodyHash[:]), ts}, "\n")
+
+	req.Header.Set("KEY", s.credentials.APIKey)
+	req.Header.Set("Timestamp", ts)
+	req.Header.Set("SIGN", hex.EncodeToString(hmacSum(sha512.New, s.credentials.SecretKey, payload)))
+	return nil
+}
+
+// AuthClient 某个账户在某个交易所的签名请求客户端
+type AuthClient struct {
+	Exchange  string
+	Account   string
+	baseURL   string
+	client    *http.Client
+	signer    Signer
+	signers   map[string]Signer // 签名方式与signer不同的接口根地址 -> 签名器，如MEXC现货API
+	timestamp func() int64      // 签名使用的毫秒时间戳
+}
+
+func NewAuthClient(exchange, account string, credentials Credentials, client *http.Client) (*AuthClient, error) {
+	signer, err := newSigner(exchange, credentials)
+	if err != nil {
+		return nil, fmt.Errorf("%s 账户 %s 凭证错误: %v", exchange, account, err)
+	}
+
+	authClient := &AuthClient{
+		Exchange: exchange,
+		Account:  account,
+		baseURL:  authBaseURLs[exchange],
+		client:   client,
+		signer:   signer,
+		timestamp: func() int64 {
+			return localNow().UnixMilli()
+		},
+	}
+	if exchange == "MEXC" {
+		authClient.signers = map[string]Signer{mexcSpotBaseURL: &mexcSpotSigner{credentials}}
+	}
+
+	return authClient, nil
+}
+
+// UseClock 签名时间戳使用按交易所服务器时间校正后的时间，避免本地时钟偏差导致请求被拒
+func (c *AuthClient) UseClock(clock *ClockSync) {
+	c.timestamp = clock.NowMs
+}
+
+// Do 发送签名请求，params为查询参数（按键名排序编码），payload不为nil时以JSON作为请求体，响应解析到result
+func (c *AuthClient) Do(method, path string, params url.Values, payload interface{}, result interface{}) error {
+	return c.DoAt(c.baseURL, method, path, params, payload, result)
+}
+
+// DoAt 向签名接口根地址以外的地址发送签名请求，如币安的杠杆接口在 api.binance.com
+func (c *AuthClient) DoAt(baseURL, method, path string, params url.Values, payload interface{}, result interface{}) error {
+	var body []byte
+	if payload != nil {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			return fmt.Errorf("序列化请求失败: %v", err)
+		}
+	}
+
+	reqURL := baseURL + path
+	if query := params.Encode(); query != "" {
+		reqURL += "?" + query
+	}
+
+	req, err := http.NewRequest(method, reqURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	if len(body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if err := c.signerFor(baseURL).Sign(req, body, c.timestamp()); err != nil {
+		return fmt.Errorf("签名失败: %v", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, truncate(string(respBody), 200))
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(respBody, result); err != nil {
+			return fmt.Errorf("解析响应失败: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// signerFor 向baseURL发送请求使用的签名器
+func (c *AuthClient) signerFor(baseURL string) Signer {
+	if signer, ok := c.signers[baseURL]; ok {
+		return signer
+	}
+	return c.signer
+}
+
+// truncate 截断过长的文本，用于错误信息
+func truncate(text string, max int) string {
+	if len(text) <= max {
+		return text
+	}
+	return text[:max] + "..."
+}
+
+// AccountClients 各账户在各交易所的签名客户端，account -> exchange -> client
+type AccountClients map[string]map[string]*AuthClient
+
+// Get 某账户在某交易所的签名客户端，未配置凭证时返回nil
+func (a AccountClients) Get(account, exchange string) *AuthClient {
+	return a[account][exchange]
+}
+
+// newAccountClients 按配置的凭证创建签名客户端，与公共行情使用相同的代理
+// 签名请求包含账户数据，不录制
+func newAccountClients(config *Config, health *HealthTracker) (AccountClients, error) {
+	clients := make(AccountClients)
+
+	for account, credentials := range config.Accounts {
+		for exchange, cred := range credentials {
+			httpClient, err := newHTTPClient(exchange, config.ProxyFor(exchange), health, nil)
+			if err != nil {
+				return nil, fmt.Errorf("%s 创建HTTP客户端失败: %v", exchange, err)
+			}
+
+			client, err := NewAuthClient(exchange, account, cred, httpClient)
+			if err != nil {
+				return nil, err
+			}
+
+			if clients[account] == nil {
+				clients[account] = make(map[string]*AuthClient)
+			}
+			clients[account][exchange] = client
+		}
+	}
+
+	return clients, nil
+}
+
+// SetAccounts 设置签名客户端，签名时间戳使用监控的校正时钟
+func (m *Monitor) SetAccounts(accounts AccountClients) {
+	for _, clients := range accounts {
+		for _, client := range clients {
+			client.UseClock(m.clock)
+		}
+	}
+	m.accounts = accounts
+}
+
+// logAccounts 输出已加载凭证的账户和交易所
+func logAccounts(accounts AccountClients) {
+	names := make([]string, 0, len(accounts))
+	for account := range accounts {
+		names = append(names, account)
+	}
+	sort.Strings(names)
+
+	for _, account := range names {
+		exchanges := make([]string, 0, len(accounts[account]))
+		for exchange := range accounts[account] {
+			exchanges = append(exchanges, exchange)
+		}
+		sort.Strings(exchanges)
+		log.Printf("账户 %s 已加载API凭证: %s", account, strings.Join(exchanges, ", "))
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// borrowRefreshInterval 借币利率的缓存时间，交易所通常按小时调整利率
+const borrowRefreshInterval = 10 * time.Minute
+
+// borrowCache 各交易所借币利率的缓存
+type borrowCache struct {
+	rates     map[string]map[string]*BorrowRate // exchange -> asset -> 利率
+	fetchedAt time.Time
+	mu        sync.Mutex
+}
+
+// fetchBorrowRates 获取所有公开借币利率的交易所数据，以及默认账户配置了凭证、可查询签名接口的交易所，缓存未过期时直接返回
+func (m *Monitor) fetchBorrowRates() map[string]map[string]*BorrowRate {
+	m.borrow.mu.Lock()
+	defer m.borrow.mu.Unlock()
+
+	if m.borrow.rates != nil && localNow().Sub(m.borrow.fetchedAt) < borrowRefreshInterval {
+		return m.borrow.rates
+	}
+
+	result := make(map[string]map[string]*BorrowRate)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, exchange := range m.exchanges {
+		var fetch func() (map[string]*BorrowRate, error)
+		switch market := exchange.(type) {
+		case BorrowMarket:
+			fetch = market.FetchBorrowRates
+		case AuthBorrowMarket:
+			client := m.accounts.Get(DefaultAccount, exchange.Name())
+			if client == nil {
+				continue
+			}
+			fetch = func() (map[string]*BorrowRate, error) {
+				return market.FetchAuthBorrowRates(client)
+			}
+		default:
+			continue
+		}
+
+		wg.Add(1)
+		go func(name string, fetch func() (map[string]*BorrowRate, error)) {
+			defer wg.Done()
+			rates, err := fetch()
+			if err != nil {
+				log.Printf("%s 获取借币利率失败: %v", name, err)
+				return
+			}
+
+			mu.Lock()
+			result[name] = rates
+			mu.Unlock()
+		}(exchange.Name(), fetch)
+	}
+
+	wg.Wait()
+
+	// 全部失败时保留上一次的数据
+	if len(result) > 0 || m.borrow.rates == nil {
+		m.borrow.rates = result
+		m.borrow.fetchedAt = localNow()
+	}
+
+	return m.borrow.rates
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// localNow 本地当前时间，回放录制数据时替换为录制时的时间
+var localNow = time.Now
+
+// ClockSync 记录各交易所服务器时间与本地时间的偏差，提供校正后的当前时间
+type ClockSync struct {
+	offsets map[string]int64 // exchange -> 服务器时间 - 本地时间（毫秒）
+	mu      sync.RWMutex
+}
+
+func NewClockSync() *ClockSync {
+	return &ClockSync{
+		offsets: make(map[string]int64),
+	}
+}
+
+// Measure 测量单个交易所的时钟偏差，以请求往返的中点作为对应的本地时间
+func (c *ClockSync) Measure(exchange Exchange) (int64, error) {
+	start := localNow()
+	serverTime, err := exchange.FetchServerTime()
+	end := localNow()
+	if err != nil {
+		return 0, err
+	}
+	if serverTime <= 0 {
+		return 0, fmt.Errorf("服务器时间无效: %d", serverTime)
+	}
+
+	localTime := start.UnixMilli() + end.Sub(start).Milliseconds()/2
+	offset := serverTime - localTime
+
+	c.mu.Lock()
+	c.offsets[exchange.Name()] = offset
+	c.mu.Unlock()
+
+	return offset, nil
+}
+
+// Offset 各交易所偏差的中位数（毫秒），尚未测量时为0
+func (c *ClockSync) Offset() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if len(c.offsets) == 0 {
+		return 0
+	}
+
+	offsets := make([]int64, 0, len(c.offsets))
+	for _, offset := range c.offsets {
+		offsets = append(offsets, offset)
+	}
+	sort.Slice(offsets, func(i, j int) bool {
+		return offsets[i] < offsets[j]
+	})
+
+	return offsets[len(offsets)/2]
+}
+
+// NowMs 校正后的当前时间（毫秒）
+func (c *ClockSync) NowMs() int64 {
+	return localNow().UnixMilli() + c.Offset()
+}
+
+// SyncClocks 测量所有交易所的时钟偏差，偏差超过阈值时输出警告
+func (m *Monitor) SyncClocks() {
+	var wg sync.WaitGroup
+	for _, exchange := range m.exchanges {
+		wg.Add(1)
+		go func(ex Exchange) {
+			defer wg.Done()
+			offset, err := m.clock.Measure(ex)
+			if err != nil {
+				log.Printf("%s 获取服务器时间失败: %v", ex.Name(), err)
+				return
+			}
+
+			if abs64(offset) > m.config.ClockOffsetWarnMs {
+				log.Printf("警告: 本地时钟与 %s 服务器相差 %dms，超过阈值 %dms", ex.Name(), offset, m.config.ClockOffsetWarnMs)
+			}
+		}(exchange)
+	}
+	wg.Wait()
+
+	log.Printf("时钟同步完成，校正偏差: %dms", m.clock.Offset())
+}
+
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
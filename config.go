@@ -0,0 +1,434 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 价差计算使用的价格来源
+const (
+	PriceSourceLast  = "last"  // 最新成交价
+	PriceSourceMark  = "mark"  // 标记价格
+	PriceSourceIndex = "index" // 指数价格
+)
+
+// 计算手续费时假设的成交方式
+const (
+	FeeOrderTaker = "taker" // 吃单成交
+	FeeOrderMaker = "maker" // 挂单成交
+)
+
+// exchangeNames 支持的交易所，用于读取按交易所覆盖的配置（如 MIN_VOLUME_USDT_GATE）
+var exchangeNames = []string{"Binance", "OKX", "Bybit", "MEXC", "Bitget", "Gate"}
+
+// defaultFeeRates 各交易所普通用户的永续合约手续费率，未配置API凭证时使用
+var defaultFeeRates = map[string]FeeRate{
+	"Binance": {Maker: 0.0002, Taker: 0.0005},
+	"OKX":     {Maker: 0.0002, Taker: 0.0005},
+	"Bybit":   {Maker: 0.0002, Taker: 0.00055},
+	"MEXC":    {Maker: 0, Taker: 0.0002},
+	"Bitget":  {Maker: 0.0002, Taker: 0.0006},
+	"Gate":    {Maker: 0.0002, Taker: 0.0005},
+}
+
+// defaultSpotFeeRates 各交易所普通用户的现货手续费率，期现套利的现货腿使用
+var defaultSpotFeeRates = map[string]FeeRate{
+	"Binance": {Maker: 0.001, Taker: 0.001},
+	"OKX":     {Maker: 0.0008, Taker: 0.001},
+	"Bybit":   {Maker: 0.001, Taker: 0.001},
+	"MEXC":    {Maker: 0, Taker: 0.0005},
+	"Bitget":  {Maker: 0.001, Taker: 0.001},
+	"Gate":    {Maker: 0.001, Taker: 0.001},
+}
+
+// LiquidityFilter 流动性过滤条件，由Monitor统一应用，可按交易所覆盖
+type LiquidityFilter struct {
+	MinVolume             float64            // 24h成交额下限（USDT）
+	MinOpenInterest       float64            // 持仓价值下限（USDT），0表示不过滤；持仓量未知的合约视为不满足
+	VolumeOverrides       map[string]float64 // exchange -> 24h成交额下限
+	OpenInterestOverrides map[string]float64 // exchange -> 持仓价值下限
+}
+
+// Allow 合约是否满足流动性要求
+func (f *LiquidityFilter) Allow(exchange string, contract *ContractData) bool {
+	if contract.QuoteVolume24h < f.MinVolumeFor(exchange) {
+		return false
+	}
+
+	// 配置了持仓价值下限时，持仓量未知（0）的合约视为不满足
+	if minOpenInterest := f.MinOpenInterestFor(exchange); minOpenInterest > 0 && contract.OpenInterestUSDT < minOpenInterest {
+		return false
+	}
+
+	return true
+}
+
+// MinOpenInterestFor 交易所适用的持仓价值下限
+func (f *LiquidityFilter) MinOpenInterestFor(exchange string) float64 {
+	if value, ok := f.OpenInterestOverrides[exchange]; ok {
+		return value
+	}
+	return f.MinOpenInterest
+}
+
+// MinVolumeFor 交易所适用的24h成交额下限
+func (f *LiquidityFilter) MinVolumeFor(exchange string) float64 {
+	if value, ok := f.VolumeOverrides[exchange]; ok {
+		return value
+	}
+	return f.MinVolume
+}
+
+// SizingConfig 仓位预算，每条腿的名义价值受单笔预算、两边交易所的资金×杠杆和盘口深度限制
+type SizingConfig struct {
+	TradeNotional     float64            // 单笔每条腿的名义价值上限（USDT）
+	Capital           float64            // 每个交易所可用的保证金（USDT），0表示不限制
+	Leverage          float64            // 每个交易所使用的最大杠杆
+	CapitalOverrides  map[string]float64 // exchange -> 保证金
+	LeverageOverrides map[string]float64 // exchange -> 最大杠杆
+}
+
+// CapitalFor 交易所可用的保证金（USDT），0表示不限制
+func (s *SizingConfig) CapitalFor(exchange string) float64 {
+	if value, ok := s.CapitalOverrides[exchange]; ok {
+		return value
+	}
+	return s.Capital
+}
+
+// LeverageFor 交易所使用的最大杠杆，未配置或小于1时按1倍计算
+func (s *SizingConfig) LeverageFor(exchange string) float64 {
+	leverage := s.Leverage
+	if value, ok := s.LeverageOverrides[exchange]; ok {
+		leverage = value
+	}
+	if leverage < 1 {
+		return 1
+	}
+	return leverage
+}
+
+// Config 运行配置，从环境变量（.env）读取
+type Config struct {
+	PriceSource string // 缺少买一/卖一价时计算价差比使用的价格：last / mark / index
+
+	// 盘口深度
+	DepthNotional    float64 // 每条腿的目标名义价值（USDT），用于估算成交均价
+	DepthLevels      int     // 获取订单簿的档位数
+	DepthMinNotional float64 // 可承载名义价值低于该值的机会不通知，0表示不过滤
+
+	CappedIntervalHour float64 // 费率触及上下限后预计缩短到的结算周期（小时），0表示不调整
+
+	// 时钟同步
+	ClockOffsetWarnMs    int64 // 本地时钟与交易所偏差超过该值（毫秒）时输出警告
+	ClockSyncIntervalMin int   // 时钟同步间隔（分钟）
+
+	Liquidity LiquidityFilter
+
+	// 新上线合约
+	NewListingWindowHour float64 // 上线后多少小时内视为新上线合约
+	NewListingThreshold  float64 // 新上线合约使用的净收益阈值，0表示使用统一阈值
+
+	IntervalChangeWindowHour float64 // 结算周期变化后多少小时内在机会中标注
+
+	SchemaDriftThreshold float64 // 单次获取中必需字段缺失比例超过该值视为接口格式变化
+
+	// 期现套利
+	SpotCarryEnabled     bool    // 是否分析现货买入 + 永续做空
+	SpotCarryHorizonHour float64 // 持有期（小时），累计持有期内的资金费
+
+	TransferCheckEnabled bool // 通知中是否附带两条腿的充提网络状态
+
+	HistoryDir string // 历史资金费率本地存储目录
+
+	RecordDir string // 录制交易所原始请求和响应的目录，空表示不录制
+
+	// 出站代理，支持 http:// https:// socks5://，之后接入的WebSocket客户端同样通过ProxyFor获取
+	ProxyURL       string            // 所有交易所默认使用的代理，空表示直连
+	ProxyOverrides map[string]string // exchange -> 代理地址，direct表示该交易所直连
+
+	// API凭证，account -> exchange -> 凭证，只包含配置了Key和Secret的交易所
+	Accounts map[string]map[string]Credentials
+
+	FeeRates     map[string]FeeRate // exchange -> 静态手续费率，账户费率查询成功前使用
+	FeeOrderType string             // 开仓和平仓按吃单还是挂单计算手续费：taker / maker
+	SpotFeeRates map[string]FeeRate // exchange -> 现货手续费率，期现套利的现货腿使用
+
+	Sizing SizingConfig
+
+	RankBy string // 套利机会的排序方式：percent / usdt / apr / margin
+
+	WindowHorizonHour float64 // 搜索开仓和平仓时间的观察期（小时）
+
+	TradeExchanges map[string]bool // 可以开仓的交易所，空表示全部；其他交易所仍获取费率，但不参与配对
+	PairsPerSymbol int             // 每个币种最多保留几对交易所
+
+	// 资金费率预测
+	ProjectionModel        string  // 下次结算之后的费率预测模型：constant / decay / premium
+	ProjectionHalfLifeHour float64 // decay模型中费率与历史均值差距的半衰期（小时）
+	ProjectionMeanDays     float64 // decay模型计算历史平均费率的天数
+}
+
+// ProxyFor 交易所使用的代理地址，空字符串表示直连
+func (c *Config) ProxyFor(exchange string) string {
+	proxyURL := c.ProxyURL
+	if value, ok := c.ProxyOverrides[exchange]; ok {
+		proxyURL = value
+	}
+	if strings.EqualFold(proxyURL, "direct") {
+		return ""
+	}
+	return proxyURL
+}
+
+func LoadConfig() *Config {
+	config := &Config{
+		PriceSource:      strings.ToLower(getEnv("PRICE_SOURCE", PriceSourceLast)),
+		DepthNotional:    getEnvFloat("DEPTH_NOTIONAL", 10000),
+		DepthLevels:      getEnvInt("DEPTH_LEVELS", 50),
+		DepthMinNotional: getEnvFloat("DEPTH_MIN_NOTIONAL", 0),
+
+		CappedIntervalHour: getEnvFloat("CAPPED_INTERVAL_HOUR", 0),
+
+		ClockOffsetWarnMs:    int64(getEnvInt("CLOCK_OFFSET_WARN_MS", 1000)),
+		ClockSyncIntervalMin: getEnvInt("CLOCK_SYNC_INTERVAL_MIN", 10),
+
+		Liquidity: LiquidityFilter{
+			MinVolume:             getEnvFloat("MIN_VOLUME_USDT", 1000000),
+			MinOpenInterest:       getEnvFloat("MIN_OPEN_INTEREST_USDT", 0),
+			VolumeOverrides:       getExchangeOverrides("MIN_VOLUME_USDT"),
+			OpenInterestOverrides: getExchangeOverrides("MIN_OPEN_INTEREST_USDT"),
+		},
+
+		NewListingWindowHour: getEnvFloat("NEW_LISTING_WINDOW_HOUR", 24),
+		NewListingThreshold:  getEnvFloat("NEW_LISTING_THRESHOLD", 0),
+
+		IntervalChangeWindowHour: getEnvFloat("INTERVAL_CHANGE_WINDOW_HOUR", 24),
+
+		SchemaDriftThreshold: getEnvFloat("SCHEMA_DRIFT_THRESHOLD", 0.5),
+
+		SpotCarryEnabled:     getEnvBool("SPOT_CARRY", false),
+		SpotCarryHorizonHour: getEnvFloat("SPOT_CARRY_HORIZON_HOUR", 8),
+
+		TransferCheckEnabled: getEnvBool("TRANSFER_CHECK", true),
+
+		HistoryDir: getEnv("HISTORY_DIR", "data/funding_history"),
+
+		RecordDir: getEnv("RECORD_DIR", ""),
+
+		ProxyURL:       getEnv("PROXY_URL", ""),
+		ProxyOverrides: getExchangeStringOverrides("PROXY_URL"),
+
+		Accounts: loadAccounts(),
+
+		FeeRates:     loadFeeRates(),
+		FeeOrderType: strings.ToLower(getEnv("FEE_ORDER_TYPE", FeeOrderTaker)),
+		SpotFeeRates: loadSpotFeeRates(),
+
+		Sizing: SizingConfig{
+			TradeNotional:     getEnvFloat("TRADE_NOTIONAL_USDT", 10000),
+			Capital:           getEnvFloat("CAPITAL_USDT", 0),
+			Leverage:          getEnvFloat("LEVERAGE", 3),
+			CapitalOverrides:  getExchangeOverrides("CAPITAL_USDT"),
+			LeverageOverrides: getExchangeOverrides("LEVERAGE"),
+		},
+
+		RankBy: strings.ToLower(getEnv("RANK_BY", RankByPercent)),
+
+		WindowHorizonHour: getEnvFloat("WINDOW_HORIZON_HOUR", 8),
+
+		TradeExchanges: loadTradeExchanges(),
+		PairsPerSymbol: getEnvInt("PAIRS_PER_SYMBOL", 3),
+
+		ProjectionModel:        strings.ToLower(getEnv("PROJECTION_MODEL", ProjectionConstant)),
+		ProjectionHalfLifeHour: getEnvFloat("PROJECTION_HALF_LIFE_HOUR", 24),
+		ProjectionMeanDays:     getEnvFloat("PROJECTION_MEAN_DAYS", 7),
+	}
+
+	if config.ClockSyncIntervalMin <= 0 {
+		config.ClockSyncIntervalMin = 10
+	}
+
+	if config.Sizing.TradeNotional <= 0 {
+		config.Sizing.TradeNotional = 10000
+	}
+
+	if config.WindowHorizonHour <= 0 {
+		config.WindowHorizonHour = 8
+	}
+
+	if config.PairsPerSymbol <= 0 {
+		config.PairsPerSymbol = 3
+	}
+
+	switch config.PriceSource {
+	case PriceSourceLast, PriceSourceMark, PriceSourceIndex:
+	default:
+		config.PriceSource = PriceSourceLast
+	}
+
+	if config.FeeOrderType != FeeOrderMaker {
+		config.FeeOrderType = FeeOrderTaker
+	}
+
+	switch config.ProjectionModel {
+	case ProjectionConstant, ProjectionDecay, ProjectionPremium:
+	default:
+		config.ProjectionModel = ProjectionConstant
+	}
+
+	switch config.RankBy {
+	case RankByPercent, RankByUSDT, RankByAPR, RankByMargin:
+	default:
+		config.RankBy = RankByPercent
+	}
+
+	return config
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, err := strconv.ParseFloat(getEnv(key, ""), 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(getEnv(key, "")); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(getEnv(key, "")); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// CanTrade 是否可以在该交易所开仓
+func (c *Config) CanTrade(exchange string) bool {
+	return len(c.TradeExchanges) == 0 || c.TradeExchanges[exchange]
+}
+
+// loadTradeExchanges 读取 TRADE_EXCHANGES，逗号分隔，不区分大小写，如 binance,okx
+func loadTradeExchanges() map[string]bool {
+	allowed := make(map[string]bool)
+	for _, value := range strings.Split(getEnv("TRADE_EXCHANGES", ""), ",") {
+		for _, name := range exchangeNames {
+			if strings.EqualFold(strings.TrimSpace(value), name) {
+				allowed[name] = true
+			}
+		}
+	}
+	return allowed
+}
+
+// getExchangeOverrides 读取按交易所覆盖的数值配置，如 MIN_VOLUME_USDT_GATE
+func getExchangeOverrides(prefix string) map[string]float64 {
+	overrides := make(map[string]float64)
+	for _, name := range exchangeNames {
+		key := prefix + "_" + strings.ToUpper(name)
+		if value, err := strconv.ParseFloat(getEnv(key, ""), 64); err == nil {
+			overrides[name] = value
+		}
+	}
+	return overrides
+}
+
+// getExchangeStringOverrides 读取按交易所覆盖的字符串配置，如 PROXY_URL_BINANCE
+func getExchangeStringOverrides(prefix string) map[string]string {
+	overrides := make(map[string]string)
+	for _, name := range exchangeNames {
+		if value := getEnv(prefix+"_"+strings.ToUpper(name), ""); value != "" {
+			overrides[name] = value
+		}
+	}
+	return overrides
+}
+
+// loadAccounts 读取各账户的API凭证
+// 默认账户读取 API_KEY_BINANCE、API_SECRET_BINANCE、API_PASSPHRASE_OKX 等，
+// ACCOUNTS 中列出的其他账户在变量名后加账户名，如 ACCOUNTS=hedge 时读取 API_KEY_BINANCE_HEDGE
+func loadAccounts() map[string]map[string]Credentials {
+	names := []string{DefaultAccount}
+	for _, name := range strings.Split(getEnv("ACCOUNTS", ""), ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" && name != DefaultAccount {
+			names = append(names, name)
+		}
+	}
+
+	accounts := make(map[string]map[string]Credentials)
+	for _, account := range names {
+		suffix := ""
+		if account != DefaultAccount {
+			suffix = "_" + strings.ToUpper(account)
+		}
+
+		for _, exchange := range exchangeNames {
+			name := strings.ToUpper(exchange) + suffix
+			credentials := Credentials{
+				APIKey:     getEnv("API_KEY_"+name, ""),
+				SecretKey:  getEnv("API_SECRET_"+name, ""),
+				Passphrase: getEnv("API_PASSPHRASE_"+name, ""),
+			}
+			if credentials.APIKey == "" || credentials.SecretKey == "" {
+				continue
+			}
+
+			if accounts[account] == nil {
+				accounts[account] = make(map[string]Credentials)
+			}
+			accounts[account][exchange] = credentials
+		}
+	}
+
+	return accounts
+}
+
+// loadSpotFeeRates 读取现货手续费率，SPOT_FEE_MAKER_<交易所>、SPOT_FEE_TAKER_<交易所> 覆盖默认值
+func loadSpotFeeRates() map[string]FeeRate {
+	makers := getExchangeOverrides("SPOT_FEE_MAKER")
+	takers := getExchangeOverrides("SPOT_FEE_TAKER")
+
+	rates := make(map[string]FeeRate)
+	for _, name := range exchangeNames {
+		rate := defaultSpotFeeRates[name]
+		if value, ok := makers[name]; ok {
+			rate.Maker = value
+		}
+		if value, ok := takers[name]; ok {
+			rate.Taker = value
+		}
+		rates[name] = rate
+	}
+	return rates
+}
+
+// loadFeeRates 读取静态手续费率，FEE_MAKER_<交易所>、FEE_TAKER_<交易所> 覆盖默认值
+func loadFeeRates() map[string]FeeRate {
+	makers := getExchangeOverrides("FEE_MAKER")
+	takers := getExchangeOverrides("FEE_TAKER")
+
+	rates := make(map[string]FeeRate)
+	for _, name := range exchangeNames {
+		rate := defaultFeeRates[name]
+		if value, ok := makers[name]; ok {
+			rate.Maker = value
+		}
+		if value, ok := takers[name]; ok {
+			rate.Taker = value
+		}
+		rates[name] = rate
+	}
+	return rates
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ContractStatus 合约的交易状态，各交易所的原始状态统一映射到这里
+type ContractStatus int
+
+const (
+	ContractStatusTrading   ContractStatus = iota // 正常交易
+	ContractStatusPending                         // 已公布但尚未开盘
+	ContractStatusSuspended                       // 暂停交易
+	ContractStatusDelisting                       // 即将下架（如Gate in_delisting）
+)
+
+// ContractEventType 合约状态变化类型
+type ContractEventType string
+
+const (
+	ContractEventListed    ContractEventType = "listed"    // 新上线
+	ContractEventDelisted  ContractEventType = "delisted"  // 已从合约列表移除
+	ContractEventSuspended ContractEventType = "suspended" // 暂停交易
+	ContractEventDelisting ContractEventType = "delisting" // 进入下架流程
+	ContractEventResumed   ContractEventType = "resumed"   // 恢复交易
+)
+
+// ContractEvent 合约上线、下架、暂停等状态变化
+type ContractEvent struct {
+	Exchange string
+	Symbol   string
+	Type     ContractEventType
+	Time     time.Time
+}
+
+// ContractStatusTracker 对比前后两次获取的合约列表，记录上线、下架和状态变化
+type ContractStatusTracker struct {
+	exchange    string
+	statuses    map[string]ContractStatus // symbol -> 上一次的状态
+	initialized bool                      // 首次更新只建立基准，不产生事件
+	events      []ContractEvent
+	mu          sync.Mutex
+}
+
+func NewContractStatusTracker(exchange string) *ContractStatusTracker {
+	return &ContractStatusTracker{
+		exchange: exchange,
+		statuses: make(map[string]ContractStatus),
+	}
+}
+
+// Update 用本次获取的完整合约列表更新状态，与上一次对比产生事件
+func (t *ContractStatusTracker) Update(current map[string]ContractStatus) {
+	// 接口异常返回空列表时不更新，避免误报全部下架
+	if len(current) == 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.initialized {
+		now := localNow()
+		for symbol, status := range current {
+			prev, existed := t.statuses[symbol]
+			if eventType, ok := contractEventType(prev, existed, status); ok {
+				t.events = append(t.events, ContractEvent{
+					Exchange: t.exchange,
+					Symbol:   symbol,
+					Type:     eventType,
+					Time:     now,
+				})
+			}
+		}
+		for symbol := range t.statuses {
+			if _, ok := current[symbol]; !ok {
+				t.events = append(t.events, ContractEvent{
+					Exchange: t.exchange,
+					Symbol:   symbol,
+					Type:     ContractEventDelisted,
+					Time:     now,
+				})
+			}
+		}
+	}
+
+	t.statuses = current
+	t.initialized = true
+}
+
+// Drain 取出并清空尚未处理的事件
+func (t *ContractStatusTracker) Drain() []ContractEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	events := t.events
+	t.events = nil
+	return events
+}
+
+// contractEventType 根据前后状态判断事件类型
+func contractEventType(prev ContractStatus, existed bool, current ContractStatus) (ContractEventType, bool) {
+	if !existed {
+		// 新出现但尚未开盘的合约，等开盘时再记为上线
+		if current == ContractStatusTrading {
+			return ContractEventListed, true
+		}
+		return "", false
+	}
+
+	if prev == current {
+		return "", false
+	}
+
+	switch current {
+	case ContractStatusTrading:
+		if prev == ContractStatusPending {
+			return ContractEventListed, true
+		}
+		return ContractEventResumed, true
+	case ContractStatusSuspended:
+		if prev == ContractStatusTrading {
+			return ContractEventSuspended, true
+		}
+	case ContractStatusDelisting:
+		return ContractEventDelisting, true
+	}
+
+	return "", false
+}
+
+// contractEventText 事件类型的中文描述
+func contractEventText(eventType ContractEventType) string {
+	switch eventType {
+	case ContractEventListed:
+		return "新上线"
+	case ContractEventDelisted:
+		return "已下架"
+	case ContractEventSuspended:
+		return "暂停交易"
+	case ContractEventDelisting:
+		return "即将下架"
+	case ContractEventResumed:
+		return "恢复交易"
+	}
+	return string(eventType)
+}
+
+// handleContractEvents 收集各交易所的合约状态变化，记录新上线时间并发送通知
+func (m *Monitor) handleContractEvents() {
+	var events []ContractEvent
+	for _, exchange := range m.exchanges {
+		events = append(events, exchange.ContractEvents()...)
+	}
+
+	if len(events) == 0 {
+		return
+	}
+
+	m.mu.Lock()
+	for _, event := range events {
+		key := event.Exchange + "_" + event.Symbol
+		switch event.Type {
+		case ContractEventListed:
+			m.listedAt[key] = event.Time
+		case ContractEventDelisted:
+			delete(m.listedAt, key)
+		}
+	}
+	m.mu.Unlock()
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Type != events[j].Type {
+			return events[i].Type < events[j].Type
+		}
+		if events[i].Symbol != events[j].Symbol {
+			return events[i].Symbol < events[j].Symbol
+		}
+		return events[i].Exchange < events[j].Exchange
+	})
+
+	message := fmt.Sprintf("📢 合约状态变化 (%d)\n\n", len(events))
+	for _, event := range events {
+		line := fmt.Sprintf("%s %s %s", contractEventText(event.Type), event.Exchange, event.Symbol)
+		log.Printf("合约状态变化: %s", line)
+		message += line + "\n"
+	}
+
+	if m.config.NewListingThreshold > 0 {
+		message += fmt.Sprintf("\n新上线合约 %.0f 小时内使用阈值 %.2f%%\n",
+			m.config.NewListingWindowHour, m.config.NewListingThreshold*100)
+	}
+
+	if m.notify == nil {
+		return
+	}
+
+	if err := m.notify(message); err != nil {
+		log.Printf("发送合约状态变化通知失败: %v", err)
+	}
+}
+
+// isNewListing 合约是否在新上线窗口内
+func (m *Monitor) isNewListing(exchange, symbol string) bool {
+	m.mu.RLock()
+	listedAt, ok := m.listedAt[exchange+"_"+symbol]
+	m.mu.RUnlock()
+
+	if !ok {
+		return false
+	}
+	return localNow().Sub(listedAt) < time.Duration(m.config.NewListingWindowHour*float64(time.Hour))
+}
+
+// newListingText 通知中新上线的交易所
+func newListingText(opp ArbitrageOpportunity) string {
+	switch {
+	case opp.HighNewListing && opp.LowNewListing:
+		return opp.HighRateExchange + " / " + opp.LowRateExchange
+	case opp.HighNewListing:
+		return opp.HighRateExchange
+	default:
+		return opp.LowRateExchange
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"log"
+	"sync"
+)
+
+// estimateFill 逐档吃单估算成交均价（VWAP），notional为目标成交额（USDT）
+// 返回成交均价和实际可成交额，盘口不足时可成交额小于目标
+func estimateFill(levels []OrderBookLevel, notional float64) (float64, float64) {
+	var filledNotional, filledQty float64
+
+	for _, level := range levels {
+		remaining := notional - filledNotional
+		if remaining <= 0 {
+			break
+		}
+
+		levelNotional := level.Price * level.Quantity
+		if levelNotional >= remaining {
+			filledQty += remaining / level.Price
+			filledNotional += remaining
+			break
+		}
+
+		filledQty += level.Quantity
+		filledNotional += levelNotional
+	}
+
+	if filledQty <= 0 {
+		return 0, 0
+	}
+	return filledNotional / filledQty, filledNotional
+}
+
+// bookNotional 盘口总名义价值（USDT）
+func bookNotional(levels []OrderBookLevel) float64 {
+	total := 0.0
+	for _, level := range levels {
+		total += level.Price * level.Quantity
+	}
+	return total
+}
+
+// fillSpread 在低费率方吃卖盘买入、高费率方吃买盘卖出notional时的价差比
+// 任一边盘口不足以成交notional时ok为false
+func fillSpread(lowAsks, highBids []OrderBookLevel, notional float64) (float64, bool) {
+	lowPrice, lowFilled := estimateFill(lowAsks, notional)
+	highPrice, highFilled := estimateFill(highBids, notional)
+
+	if lowPrice <= 0 || highPrice <= 0 {
+		return 0, false
+	}
+
+	spread := (lowPrice - highPrice) / highPrice
+	// 允许浮点误差
+	ok := lowFilled >= notional*0.9999 && highFilled >= notional*0.9999
+	return spread, ok
+}
+
+// maxProfitableNotional 二分查找净收益仍高于阈值的最大名义价值（USDT），fundingEdge为扣除手续费后的资金费差
+// 成交均价随名义价值单调变差，所以净收益随名义价值单调不增
+func maxProfitableNotional(lowAsks, highBids []OrderBookLevel, fundingEdge, threshold float64) float64 {
+	upper := bookNotional(lowAsks)
+	if highNotional := bookNotional(highBids); highNotional < upper {
+		upper = highNotional
+	}
+	if upper <= 0 {
+		return 0
+	}
+
+	profitable := func(notional float64) bool {
+		spread, ok := fillSpread(lowAsks, highBids, notional)
+		return ok && fundingEdge-spread > threshold
+	}
+
+	if profitable(upper) {
+		return upper
+	}
+
+	low, high := 0.0, upper
+	for i := 0; i < 40; i++ {
+		mid := (low + high) / 2
+		if profitable(mid) {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+
+	return low
+}
+
+// applyDepth 对通过阈值的机会获取两边订单簿，估算目标名义价值下的成交均价和最大可承载名义价值
+func (m *Monitor) applyDepth(opportunities []ArbitrageOpportunity) []ArbitrageOpportunity {
+	type bookKey struct {
+		exchange string
+		symbol   string
+	}
+
+	// 每个交易所+币种只获取一次订单簿
+	needed := make(map[bookKey]bool)
+	for _, opp := range opportunities {
+		needed[bookKey{opp.HighRateExchange, opp.Symbol}] = true
+		needed[bookKey{opp.LowRateExchange, opp.Symbol}] = true
+	}
+
+	books := make(map[bookKey]*OrderBook)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for key := range needed {
+		exchange := m.getExchange(key.exchange)
+		if exchange == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(key bookKey, ex Exchange) {
+			defer wg.Done()
+			book, err := ex.FetchOrderBook(key.symbol, m.config.DepthLevels)
+			if err != nil {
+				log.Printf("%s 获取 %s 订单簿失败: %v", key.exchange, key.symbol, err)
+				return
+			}
+			mu.Lock()
+			books[key] = book
+			mu.Unlock()
+		}(key, exchange)
+	}
+	wg.Wait()
+
+	notional := m.config.DepthNotional
+
+	var result []ArbitrageOpportunity
+	for _, opp := range opportunities {
+		highBook := books[bookKey{opp.HighRateExchange, opp.Symbol}]
+		lowBook := books[bookKey{opp.LowRateExchange, opp.Symbol}]
+
+		// 订单簿获取失败时保留盘口价差的结果
+		if highBook == nil || lowBook == nil {
+			result = append(result, opp)
+			continue
+		}
+
+		// 手续费按名义价值比例收取，不随成交量变化
+		fundingEdge := opp.FundingEdge - opp.FeeCost
+
+		opp.DepthChecked = true
+		opp.TargetNotional = notional
+		opp.LowFillPrice, _ = estimateFill(lowBook.Asks, notional)
+		opp.HighFillPrice, _ = estimateFill(highBook.Bids, notional)
+		opp.FillSpread, opp.TargetFillable = fillSpread(lowBook.Asks, highBook.Bids, notional)
+		opp.FillNetProfit = fundingEdge - opp.FillSpread
+		opp.MaxNotional = maxProfitableNotional(lowBook.Asks, highBook.Bids, fundingEdge, opp.Threshold)
+		m.applySizing(&opp, lowBook.Asks, highBook.Bids)
+
+		// 盘口上任何仓位的净收益都不高于阈值，没有可建议的仓位
+		if opp.RecommendedNotional <= 0 {
+			continue
+		}
+		if m.config.DepthMinNotional > 0 && opp.MaxNotional < m.config.DepthMinNotional {
+			continue
+		}
+
+		result = append(result, opp)
+	}
+
+	return result
+}
@@ -4,23 +4,37 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 )
 
+// binancePerpetualDeliveryDate 永续合约默认的交割时间（2100-12-25），早于该时间表示已排期下架
+const binancePerpetualDeliveryDate = 4133404800000
+
 type BinanceExchange struct {
 	client            *http.Client
 	fundingIntervals  map[string]float64 // symbol -> interval in hours
 	tradingSymbols    map[string]bool    // symbol -> is trading
+	fundingLimits     map[string]FundingRateLimit
+	intervalChanges   *IntervalTracker
+	statusTracker    *ContractStatusTracker
+	schemaChecks      *SchemaTracker
 	mu                sync.RWMutex
 }
 
-func NewBinanceExchange() *BinanceExchange {
+func NewBinanceExchange(client *http.Client) *BinanceExchange {
 	return &BinanceExchange{
-		client:           &http.Client{Timeout: 10 * time.Second},
+		client:           client,
 		fundingIntervals: make(map[string]float64),
 		tradingSymbols:   make(map[string]bool),
+		fundingLimits:    make(map[string]FundingRateLimit),
+		intervalChanges:  NewIntervalTracker("Binance"),
+		statusTracker:    NewContractStatusTracker("Binance"),
+		schemaChecks:     NewSchemaTracker("Binance"),
 	}
 }
 
@@ -28,6 +42,11 @@ func (b *BinanceExchange) Name() string {
 	return "Binance"
 }
 
+// premiumIndex的lastFundingRate为本期实时预估费率，结算前会持续变化
+func (b *BinanceExchange) FundingRatePredicted() bool {
+	return true
+}
+
 func (b *BinanceExchange) Initialize() error {
 	return nil
 }
@@ -47,20 +66,35 @@ func (b *BinanceExchange) UpdateFundingIntervals() error {
 	}
 
 	var fundingInfos []struct {
-		Symbol               string `json:"symbol"`
-		FundingIntervalHours int    `json:"fundingIntervalHours"`
+		Symbol                   string `json:"symbol"`
+		AdjustedFundingRateCap   string `json:"adjustedFundingRateCap"`
+		AdjustedFundingRateFloor string `json:"adjustedFundingRateFloor"`
+		FundingIntervalHours     int    `json:"fundingIntervalHours"`
 	}
 
 	if err := json.Unmarshal(body, &fundingInfos); err != nil {
 		return fmt.Errorf("解析响应失败: %v", err)
 	}
 
+	// fundingInfo只包含调整过周期或上下限的合约，不在列表中的合约使用默认8小时
+	check := b.schemaChecks.Begin(SchemaEndpointInterval)
+	for _, info := range fundingInfos {
+		check.Item()
+		check.Require("fundingIntervalHours", info.FundingIntervalHours > 0)
+		check.Require("adjustedFundingRateCap", parseFloat(info.AdjustedFundingRateCap) > 0)
+	}
+	b.schemaChecks.Record(check)
+
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	
 	for _, info := range fundingInfos {
 		if info.FundingIntervalHours > 0 {
-			b.fundingIntervals[info.Symbol] = float64(info.FundingIntervalHours)
+			updateFundingInterval(b.fundingIntervals, b.intervalChanges, info.Symbol, float64(info.FundingIntervalHours))
+		}
+		b.fundingLimits[info.Symbol] = FundingRateLimit{
+			Cap:   parseFloat(info.AdjustedFundingRateCap),
+			Floor: parseFloat(info.AdjustedFundingRateFloor),
 		}
 	}
 
@@ -85,6 +119,24 @@ func (b *BinanceExchange) isTrading(symbol string) bool {
 	return ok && trading
 }
 
+// ContractEvents 取出合约上线、下架和状态变化事件
+func (b *BinanceExchange) ContractEvents() []ContractEvent {
+	return b.statusTracker.Drain()
+}
+
+// IntervalChanges 取出尚未通知的结算周期变化
+func (b *BinanceExchange) IntervalChanges() []IntervalChange {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.intervalChanges.Drain()
+}
+
+// SchemaChecks 取出每次获取的必需字段检查结果
+func (b *BinanceExchange) SchemaChecks() []SchemaCheck {
+	return b.schemaChecks.Drain()
+}
+
 func (b *BinanceExchange) UpdateContractStatus() error {
 	url := "https://fapi.binance.com/fapi/v1/exchangeInfo"
 	
@@ -101,8 +153,11 @@ func (b *BinanceExchange) UpdateContractStatus() error {
 
 	var exchangeInfo struct {
 		Symbols []struct {
-			Symbol string `json:"symbol"`
-			Status string `json:"status"`
+			Symbol       string `json:"symbol"`
+			Status       string `json:"status"`
+			ContractType string `json:"contractType"`
+			QuoteAsset   string `json:"quoteAsset"`
+			DeliveryDate int64  `json:"deliveryDate"` // 永续合约默认为2100年，排期下架后改为下架时间
 		} `json:"symbols"`
 	}
 
@@ -110,13 +165,33 @@ func (b *BinanceExchange) UpdateContractStatus() error {
 		return fmt.Errorf("解析响应失败: %v", err)
 	}
 
-	b.mu.Lock()
-	defer b.mu.Unlock()
-	
+	tradingSymbols := make(map[string]bool)
+	statuses := make(map[string]ContractStatus)
+
 	for _, symbol := range exchangeInfo.Symbols {
-		b.tradingSymbols[symbol.Symbol] = (symbol.Status == "TRADING")
+		tradingSymbols[symbol.Symbol] = (symbol.Status == "TRADING")
+
+		if symbol.ContractType != "PERPETUAL" || symbol.QuoteAsset != "USDT" {
+			continue
+		}
+		switch {
+		case symbol.Status == "TRADING" && symbol.DeliveryDate > 0 && symbol.DeliveryDate < binancePerpetualDeliveryDate:
+			statuses[symbol.Symbol] = ContractStatusDelisting
+		case symbol.Status == "TRADING":
+			statuses[symbol.Symbol] = ContractStatusTrading
+		case symbol.Status == "PENDING_TRADING":
+			statuses[symbol.Symbol] = ContractStatusPending
+		default:
+			statuses[symbol.Symbol] = ContractStatusSuspended
+		}
 	}
 
+	b.mu.Lock()
+	b.tradingSymbols = tradingSymbols
+	b.mu.Unlock()
+
+	b.statusTracker.Update(statuses)
+
 	return nil
 }
 
@@ -137,6 +212,8 @@ func (b *BinanceExchange) FetchFundingRates() (map[string]*ContractData, error)
 
 	var premiumIndexes []struct {
 		Symbol          string `json:"symbol"`
+		MarkPrice       string `json:"markPrice"`
+		IndexPrice      string `json:"indexPrice"`
 		LastFundingRate string `json:"lastFundingRate"`
 		NextFundingTime int64  `json:"nextFundingTime"`
 	}
@@ -186,8 +263,15 @@ func (b *BinanceExchange) FetchFundingRates() (map[string]*ContractData, error)
 		}
 	}
 
+	// 3. 使用 /fapi/v1/ticker/bookTicker 获取买一卖一价，只用于价差，获取失败时本轮按 PRICE_SOURCE 的价格计算价差
+	bookMap, err := b.fetchBookTickers()
+	if err != nil {
+		log.Printf("Binance 获取买一卖一价失败，本轮不使用盘口价格: %v", err)
+	}
+
 	result := make(map[string]*ContractData)
-	
+	check := b.schemaChecks.Begin(SchemaEndpointFunding)
+
 	for _, item := range premiumIndexes {
 		// 只处理USDT合约
 		if len(item.Symbol) < 4 || item.Symbol[len(item.Symbol)-4:] != "USDT" {
@@ -201,28 +285,352 @@ func (b *BinanceExchange) FetchFundingRates() (map[string]*ContractData, error)
 
 		// 获取价格和交易额
 		ticker, ok := tickerMap[item.Symbol]
+
+		check.Item()
+		check.Require("lastPrice", ok && ticker.Price > 0)
+		check.Require("markPrice", parseFloat(item.MarkPrice) > 0)
+		check.Require("lastFundingRate", item.LastFundingRate != "")
+		check.Require("nextFundingTime", validNextFundingTime(item.NextFundingTime))
+
 		if !ok || ticker.Price <= 0 {
 			continue
 		}
-		
-		// 过滤24h交易额小于100万的合约
-		if ticker.QuoteVolume < 1000000 {
-			continue
-		}
-		
+
 		fundingRate := parseFloat(item.LastFundingRate)
 		intervalHour := b.getFundingInterval(item.Symbol)
 
 		// 转换为4小时费率
 		fundingRate4h := fundingRate * (4.0 / intervalHour)
 		
-		result[item.Symbol] = &ContractData{
+		contract := &ContractData{
 			Symbol:              item.Symbol,
 			Price:               ticker.Price,
+			MarkPrice:           parseFloat(item.MarkPrice),
+			IndexPrice:          parseFloat(item.IndexPrice),
+			BidPrice:            bookMap[item.Symbol].BidPrice,
+			AskPrice:            bookMap[item.Symbol].AskPrice,
 			FundingRate:         fundingRate,
 			FundingIntervalHour: intervalHour,
 			FundingRate4h:       fundingRate4h,
 			NextFundingTime:     item.NextFundingTime,
+
+			FundingRatePredicted: b.FundingRatePredicted(),
+			QuoteVolume24h:       ticker.QuoteVolume, // 币安没有批量获取持仓量的公开接口，OpenInterestUSDT由Monitor按币种查询后填入
+		}
+
+		b.mu.RLock()
+		applyFundingLimit(contract, b.fundingLimits)
+		applyIntervalChange(contract, b.intervalChanges)
+		b.mu.RUnlock()
+
+		result[item.Symbol] = contract
+	}
+
+	b.schemaChecks.Record(check)
+
+	return result, nil
+}
+
+// binanceBookTicker 买一卖一价
+type binanceBookTicker struct {
+	BidPrice float64
+	AskPrice float64
+}
+
+// fetchBookTickers 获取所有合约的买一卖一价，symbol -> 买一卖一价
+func (b *BinanceExchange) fetchBookTickers() (map[string]binanceBookTicker, error) {
+	bookURL := "https://fapi.binance.com/fapi/v1/ticker/bookTicker"
+
+	bookResp, err := b.client.Get(bookURL)
+	if err != nil {
+		return nil, fmt.Errorf("请求bookTicker失败: %v", err)
+	}
+	defer bookResp.Body.Close()
+
+	bookBody, err := io.ReadAll(bookResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取bookTicker响应失败: %v", err)
+	}
+
+	var bookTickers []struct {
+		Symbol   string `json:"symbol"`
+		BidPrice string `json:"bidPrice"`
+		AskPrice string `json:"askPrice"`
+	}
+
+	if err := json.Unmarshal(bookBody, &bookTickers); err != nil {
+		return nil, fmt.Errorf("解析bookTicker响应失败: %v", err)
+	}
+
+	bookMap := make(map[string]binanceBookTicker)
+	for _, t := range bookTickers {
+		bookMap[t.Symbol] = binanceBookTicker{
+			BidPrice: parseFloat(t.BidPrice),
+			AskPrice: parseFloat(t.AskPrice),
+		}
+	}
+
+	return bookMap, nil
+}
+
+func (b *BinanceExchange) FetchOrderBook(symbol string, limit int) (*OrderBook, error) {
+	url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/depth?symbol=%s&limit=%d", symbol, limit)
+
+	resp, err := b.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("请求订单簿失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取订单簿响应失败: %v", err)
+	}
+
+	var depth struct {
+		Bids [][]interface{} `json:"bids"`
+		Asks [][]interface{} `json:"asks"`
+	}
+
+	if err := json.Unmarshal(body, &depth); err != nil {
+		return nil, fmt.Errorf("解析订单簿响应失败: %v", err)
+	}
+
+	return &OrderBook{
+		Symbol: symbol,
+		Bids:   parseBookLevels(depth.Bids, 1),
+		Asks:   parseBookLevels(depth.Asks, 1),
+	}, nil
+}
+
+func (b *BinanceExchange) FetchServerTime() (int64, error) {
+	resp, err := b.client.Get("https://fapi.binance.com/fapi/v1/time")
+	if err != nil {
+		return 0, fmt.Errorf("请求服务器时间失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("读取服务器时间响应失败: %v", err)
+	}
+
+	var response struct {
+		ServerTime int64 `json:"serverTime"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, fmt.Errorf("解析服务器时间响应失败: %v", err)
+	}
+
+	return response.ServerTime, nil
+}
+
+// binanceMarginBaseURL 币安杠杆接口的根地址，与合约接口不同
+const binanceMarginBaseURL = "https://api.binance.com"
+
+// FetchAuthBorrowRates 查询全仓杠杆各币种的借币利率，接口需要签名，按账户VIP等级返回
+func (b *BinanceExchange) FetchAuthBorrowRates(client *AuthClient) (map[string]*BorrowRate, error) {
+	var response []struct {
+		Coin          string `json:"coin"`
+		Borrowable    bool   `json:"borrowable"`
+		DailyInterest string `json:"dailyInterest"` // 日利率
+	}
+
+	if err := client.DoAt(binanceMarginBaseURL, http.MethodGet, "/sapi/v1/margin/crossMarginData", nil, nil, &response); err != nil {
+		return nil, fmt.Errorf("请求借币利率失败: %v", err)
+	}
+
+	result := make(map[string]*BorrowRate)
+	for _, item := range response {
+		result[item.Coin] = &BorrowRate{
+			Asset:      item.Coin,
+			HourlyRate: parseFloat(item.DailyInterest) / 24,
+			Borrowable: item.Borrowable,
+		}
+	}
+
+	return result, nil
+}
+
+// FetchOpenInterest 按币种查询持仓量（币），币安没有批量获取持仓量的公开接口
+func (b *BinanceExchange) FetchOpenInterest(symbol string) (float64, error) {
+	resp, err := b.client.Get("https://fapi.binance.com/fapi/v1/openInterest?symbol=" + symbol)
+	if err != nil {
+		return 0, fmt.Errorf("请求持仓量失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("读取持仓量响应失败: %v", err)
+	}
+
+	var response struct {
+		Symbol       string `json:"symbol"`
+		OpenInterest string `json:"openInterest"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, fmt.Errorf("解析持仓量响应失败: %v", err)
+	}
+	if response.OpenInterest == "" {
+		return 0, fmt.Errorf("响应中没有持仓量: %s", string(body))
+	}
+
+	return parseFloat(response.OpenInterest), nil
+}
+
+func (b *BinanceExchange) FetchFundingHistory(symbol string, startTime, endTime int64) ([]FundingRecord, error) {
+	const limit = 1000
+	var records []FundingRecord
+
+	// 结果按时间升序，以最后一条的时间作为下一页的起点
+	from := startTime
+	for page := 0; page < historyMaxPages && from <= endTime; page++ {
+		url := fmt.Sprintf("https://fapi.binance.com/fapi/v1/fundingRate?symbol=%s&startTime=%d&endTime=%d&limit=%d",
+			symbol, from, endTime, limit)
+
+		resp, err := b.client.Get(url)
+		if err != nil {
+			return nil, fmt.Errorf("请求历史资金费率失败: %v", err)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取历史资金费率响应失败: %v", err)
+		}
+
+		var items []struct {
+			FundingTime int64  `json:"fundingTime"`
+			FundingRate string `json:"fundingRate"`
+		}
+
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, fmt.Errorf("解析历史资金费率响应失败: %v", err)
+		}
+
+		for _, item := range items {
+			records = append(records, FundingRecord{
+				Exchange:    b.Name(),
+				Symbol:      symbol,
+				FundingTime: item.FundingTime,
+				FundingRate: parseFloat(item.FundingRate),
+			})
+		}
+
+		if len(items) < limit {
+			break
+		}
+		from = items[len(items)-1].FundingTime + 1
+		time.Sleep(historyPageDelay)
+	}
+
+	return records, nil
+}
+
+func (b *BinanceExchange) FetchSpotTickers() (map[string]*SpotTicker, error) {
+	resp, err := b.client.Get("https://api.binance.com/api/v3/ticker/24hr")
+	if err != nil {
+		return nil, fmt.Errorf("请求现货行情失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取现货行情响应失败: %v", err)
+	}
+
+	var tickers []struct {
+		Symbol      string `json:"symbol"`
+		LastPrice   string `json:"lastPrice"`
+		BidPrice    string `json:"bidPrice"`
+		AskPrice    string `json:"askPrice"`
+		QuoteVolume string `json:"quoteVolume"`
+	}
+
+	if err := json.Unmarshal(body, &tickers); err != nil {
+		return nil, fmt.Errorf("解析现货行情响应失败: %v", err)
+	}
+
+	result := make(map[string]*SpotTicker)
+	for _, ticker := range tickers {
+		if !strings.HasSuffix(ticker.Symbol, "USDT") {
+			continue
+		}
+		price := parseFloat(ticker.LastPrice)
+		if price <= 0 {
+			continue
+		}
+		result[ticker.Symbol] = &SpotTicker{
+			Symbol:         ticker.Symbol,
+			Price:          price,
+			BidPrice:       parseFloat(ticker.BidPrice),
+			AskPrice:       parseFloat(ticker.AskPrice),
+			QuoteVolume24h: parseFloat(ticker.QuoteVolume),
+		}
+	}
+
+	return result, nil
+}
+
+// FetchFeeRate 查询账户的U本位合约手续费率，按BTCUSDT的费率代表账户等级
+func (b *BinanceExchange) FetchFeeRate(client *AuthClient) (FeeRate, error) {
+	params := url.Values{}
+	params.Set("symbol", feeReferenceSymbol)
+
+	var response struct {
+		Symbol              string `json:"symbol"`
+		MakerCommissionRate string `json:"makerCommissionRate"`
+		TakerCommissionRate string `json:"takerCommissionRate"`
+	}
+
+	if err := client.Do(http.MethodGet, "/fapi/v1/commissionRate", params, nil, &response); err != nil {
+		return FeeRate{}, fmt.Errorf("请求手续费率失败: %v", err)
+	}
+
+	if response.TakerCommissionRate == "" {
+		return FeeRate{}, fmt.Errorf("响应中没有手续费率")
+	}
+
+	return FeeRate{
+		Maker: parseFloat(response.MakerCommissionRate),
+		Taker: parseFloat(response.TakerCommissionRate),
+	}, nil
+}
+
+// FetchAuthNetworkStatus 查询币种各链的充提状态，币安的充提接口需要签名，返回所有币种后按asset筛选
+func (b *BinanceExchange) FetchAuthNetworkStatus(client *AuthClient, asset string) ([]NetworkStatus, error) {
+	var response []struct {
+		Coin        string `json:"coin"`
+		NetworkList []struct {
+			Network        string `json:"network"`
+			DepositEnable  bool   `json:"depositEnable"`
+			WithdrawEnable bool   `json:"withdrawEnable"`
+			WithdrawFee    string `json:"withdrawFee"`
+			MinConfirm     int    `json:"minConfirm"`
+		} `json:"networkList"`
+	}
+
+	if err := client.DoAt(binanceMarginBaseURL, http.MethodGet, "/sapi/v1/capital/config/getall", nil, nil, &response); err != nil {
+		return nil, fmt.Errorf("请求充提状态失败: %v", err)
+	}
+
+	var result []NetworkStatus
+	for _, coin := range response {
+		if coin.Coin != asset {
+			continue
+		}
+		for _, network := range coin.NetworkList {
+			result = append(result, NetworkStatus{
+				Asset:           asset,
+				Network:         normalizeNetwork(network.Network),
+				DepositEnabled:  network.DepositEnable,
+				WithdrawEnabled: network.WithdrawEnable,
+				WithdrawFee:     parseWithdrawFee(network.WithdrawFee),
+				Confirmations:   network.MinConfirm,
+			})
 		}
 	}
 
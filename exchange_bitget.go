@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"sync"
 	"time"
 )
@@ -13,14 +14,20 @@ type BitgetExchange struct {
 	client            *http.Client
 	fundingIntervals  map[string]float64 // symbol -> interval in hours
 	tradingSymbols    map[string]bool    // symbol -> is trading
+	intervalChanges   *IntervalTracker
+	statusTracker    *ContractStatusTracker
+	schemaChecks      *SchemaTracker
 	mu                sync.RWMutex
 }
 
-func NewBitgetExchange() *BitgetExchange {
+func NewBitgetExchange(client *http.Client) *BitgetExchange {
 	return &BitgetExchange{
-		client:           &http.Client{Timeout: 10 * time.Second},
+		client:           client,
 		fundingIntervals: make(map[string]float64),
 		tradingSymbols:   make(map[string]bool),
+		intervalChanges:  NewIntervalTracker("Bitget"),
+		statusTracker:    NewContractStatusTracker("Bitget"),
+		schemaChecks:     NewSchemaTracker("Bitget"),
 	}
 }
 
@@ -28,6 +35,11 @@ func (b *BitgetExchange) Name() string {
 	return "Bitget"
 }
 
+// current-fund-rate的fundingRate为本期实时预估费率，结算前会持续变化
+func (b *BitgetExchange) FundingRatePredicted() bool {
+	return true
+}
+
 func (b *BitgetExchange) Initialize() error {
 	return nil
 }
@@ -65,6 +77,13 @@ func (b *BitgetExchange) UpdateFundingIntervals() error {
 		return fmt.Errorf("API返回错误: %s - %s", response.Code, response.Msg)
 	}
 
+	check := b.schemaChecks.Begin(SchemaEndpointInterval)
+	for _, item := range response.Data {
+		check.Item()
+		check.Require("fundingRateInterval", parseFloat(item.FundingRateInterval) > 0)
+	}
+	b.schemaChecks.Record(check)
+
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
@@ -72,7 +91,7 @@ func (b *BitgetExchange) UpdateFundingIntervals() error {
 		intervalHour := parseFloat(item.FundingRateInterval)
 		if intervalHour > 0 {
 			// Bitget的symbol格式如 BTCUSDT
-			b.fundingIntervals[item.Symbol] = intervalHour
+			updateFundingInterval(b.fundingIntervals, b.intervalChanges, item.Symbol, intervalHour)
 		}
 	}
 
@@ -97,6 +116,24 @@ func (b *BitgetExchange) isTrading(symbol string) bool {
 	return ok && trading
 }
 
+// ContractEvents 取出合约上线、下架和状态变化事件
+func (b *BitgetExchange) ContractEvents() []ContractEvent {
+	return b.statusTracker.Drain()
+}
+
+// IntervalChanges 取出尚未通知的结算周期变化
+func (b *BitgetExchange) IntervalChanges() []IntervalChange {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.intervalChanges.Drain()
+}
+
+// SchemaChecks 取出每次获取的必需字段检查结果
+func (b *BitgetExchange) SchemaChecks() []SchemaCheck {
+	return b.schemaChecks.Drain()
+}
+
 func (b *BitgetExchange) UpdateContractStatus() error {
 	url := "https://api.bitget.com/api/v2/mix/market/contracts?productType=USDT-FUTURES"
 	
@@ -128,13 +165,32 @@ func (b *BitgetExchange) UpdateContractStatus() error {
 		return fmt.Errorf("API返回错误: %s - %s", response.Code, response.Msg)
 	}
 
-	b.mu.Lock()
-	defer b.mu.Unlock()
-	
+	tradingSymbols := make(map[string]bool)
+	statuses := make(map[string]ContractStatus)
+
 	for _, item := range response.Data {
-		b.tradingSymbols[item.Symbol] = (item.SymbolStatus == "normal")
+		tradingSymbols[item.Symbol] = (item.SymbolStatus == "normal")
+
+		// symbolStatus: listed 已上架未开盘，normal 正常，limit_open 限制开仓（下架前），
+		// maintain 维护，restrictedAPI 限制API下单，off 下线
+		switch item.SymbolStatus {
+		case "normal":
+			statuses[item.Symbol] = ContractStatusTrading
+		case "listed":
+			statuses[item.Symbol] = ContractStatusPending
+		case "limit_open":
+			statuses[item.Symbol] = ContractStatusDelisting
+		default:
+			statuses[item.Symbol] = ContractStatusSuspended
+		}
 	}
 
+	b.mu.Lock()
+	b.tradingSymbols = tradingSymbols
+	b.mu.Unlock()
+
+	b.statusTracker.Update(statuses)
+
 	return nil
 }
 
@@ -159,8 +215,13 @@ func (b *BitgetExchange) FetchFundingRates() (map[string]*ContractData, error) {
 		Data []struct {
 			Symbol      string `json:"symbol"`
 			LastPr      string `json:"lastPr"`
+			MarkPrice   string `json:"markPrice"`
+			IndexPrice  string `json:"indexPrice"`
+			BidPr       string `json:"bidPr"`
+			AskPr       string `json:"askPr"`
 			FundingRate string `json:"fundingRate"`
-			QuoteVolume string `json:"quoteVolume"` // 24h成交额
+			QuoteVolume   string `json:"quoteVolume"`   // 24h成交额
+			HoldingAmount string `json:"holdingAmount"` // 持仓量（币）
 		} `json:"data"`
 	}
 
@@ -195,6 +256,8 @@ func (b *BitgetExchange) FetchFundingRates() (map[string]*ContractData, error) {
 			FundingRate         string `json:"fundingRate"`
 			FundingRateInterval string `json:"fundingRateInterval"` // 单位：小时
 			NextUpdate          string `json:"nextUpdate"`          // 下次更新时间戳（毫秒）
+			MaxFundingRate      string `json:"maxFundingRate"`      // 资金费率上限
+			MinFundingRate      string `json:"minFundingRate"`      // 资金费率下限
 		} `json:"data"`
 	}
 
@@ -209,7 +272,8 @@ func (b *BitgetExchange) FetchFundingRates() (map[string]*ContractData, error) {
 	// 构建资金费率周期和下次结算时间映射
 	fundingIntervalMap := make(map[string]float64)
 	nextFundingTimeMap := make(map[string]int64)
-	
+	fundingLimitMap := make(map[string]FundingRateLimit)
+
 	for _, item := range fundingResponse.Data {
 		intervalHour := parseFloat(item.FundingRateInterval)
 		if intervalHour > 0 {
@@ -217,9 +281,14 @@ func (b *BitgetExchange) FetchFundingRates() (map[string]*ContractData, error) {
 			
 			// 更新缓存
 			b.mu.Lock()
-			b.fundingIntervals[item.Symbol] = intervalHour
+			updateFundingInterval(b.fundingIntervals, b.intervalChanges, item.Symbol, intervalHour)
 			b.mu.Unlock()
 		}
+
+		fundingLimitMap[item.Symbol] = FundingRateLimit{
+			Cap:   parseFloat(item.MaxFundingRate),
+			Floor: parseFloat(item.MinFundingRate),
+		}
 		
 		nextUpdate := parseInt64(item.NextUpdate)
 		if nextUpdate > 0 {
@@ -228,6 +297,7 @@ func (b *BitgetExchange) FetchFundingRates() (map[string]*ContractData, error) {
 	}
 
 	result := make(map[string]*ContractData)
+	check := b.schemaChecks.Begin(SchemaEndpointFunding)
 	
 	for _, item := range response.Data {
 		// 只处理USDT合约（symbol不包含下划线或特殊后缀）
@@ -241,15 +311,17 @@ func (b *BitgetExchange) FetchFundingRates() (map[string]*ContractData, error) {
 		}
 
 		price := parseFloat(item.LastPr)
+
+		check.Item()
+		check.Require("lastPr", price > 0)
+		check.Require("markPrice", parseFloat(item.MarkPrice) > 0)
+		check.Require("fundingRate", item.FundingRate != "")
+		check.Require("fundingRateInterval", fundingIntervalMap[item.Symbol] > 0)
+		check.Require("nextUpdate", validNextFundingTime(nextFundingTimeMap[item.Symbol]))
+
 		if price <= 0 {
 			continue
 		}
-		
-		// 过滤24h交易额小于100万的合约
-		quoteVolume := parseFloat(item.QuoteVolume)
-		if quoteVolume < 1000000 {
-			continue
-		}
 
 		fundingRate := parseFloat(item.FundingRate)
 		
@@ -262,13 +334,268 @@ func (b *BitgetExchange) FetchFundingRates() (map[string]*ContractData, error) {
 		// 获取下次结算时间
 		nextFundingTime := nextFundingTimeMap[item.Symbol]
 
-		result[item.Symbol] = &ContractData{
+		contract := &ContractData{
 			Symbol:              item.Symbol,
 			Price:               price,
+			MarkPrice:           parseFloat(item.MarkPrice),
+			IndexPrice:          parseFloat(item.IndexPrice),
+			BidPrice:            parseFloat(item.BidPr),
+			AskPrice:            parseFloat(item.AskPr),
 			FundingRate:         fundingRate,
 			FundingIntervalHour: intervalHour,
 			FundingRate4h:       fundingRate * (4.0 / intervalHour), // 保留用于兼容性
 			NextFundingTime:     nextFundingTime,
+
+			FundingRatePredicted: b.FundingRatePredicted(),
+			QuoteVolume24h:       parseFloat(item.QuoteVolume),
+		}
+
+		// 持仓价值 = 持仓量（币） × 标记价格
+		contract.OpenInterestUSDT = parseFloat(item.HoldingAmount) * contract.PriceFor(PriceSourceMark)
+
+		applyFundingLimit(contract, fundingLimitMap)
+		b.mu.RLock()
+		applyIntervalChange(contract, b.intervalChanges)
+		b.mu.RUnlock()
+
+		result[item.Symbol] = contract
+	}
+
+	b.schemaChecks.Record(check)
+
+	return result, nil
+}
+
+func (b *BitgetExchange) FetchOrderBook(symbol string, limit int) (*OrderBook, error) {
+	url := fmt.Sprintf("https://api.bitget.com/api/v2/mix/market/merge-depth?symbol=%s&productType=USDT-FUTURES&limit=%d", symbol, limit)
+
+	resp, err := b.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("请求订单簿失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取订单簿响应失败: %v", err)
+	}
+
+	var response struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			Bids [][]interface{} `json:"bids"`
+			Asks [][]interface{} `json:"asks"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("解析订单簿响应失败: %v", err)
+	}
+
+	if response.Code != "00000" {
+		return nil, fmt.Errorf("API返回错误: %s - %s", response.Code, response.Msg)
+	}
+
+	return &OrderBook{
+		Symbol: symbol,
+		Bids:   parseBookLevels(response.Data.Bids, 1),
+		Asks:   parseBookLevels(response.Data.Asks, 1),
+	}, nil
+}
+
+func (b *BitgetExchange) FetchServerTime() (int64, error) {
+	resp, err := b.client.Get("https://api.bitget.com/api/v2/public/time")
+	if err != nil {
+		return 0, fmt.Errorf("请求服务器时间失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("读取服务器时间响应失败: %v", err)
+	}
+
+	var response struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			ServerTime string `json:"serverTime"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, fmt.Errorf("解析服务器时间响应失败: %v", err)
+	}
+
+	if response.Code != "00000" {
+		return 0, fmt.Errorf("API返回错误: %s - %s", response.Code, response.Msg)
+	}
+
+	return parseInt64(response.Data.ServerTime), nil
+}
+
+func (b *BitgetExchange) FetchFundingHistory(symbol string, startTime, endTime int64) ([]FundingRecord, error) {
+	const pageSize = 100
+	var records []FundingRecord
+
+	// 接口不支持时间范围，按页倒序翻到早于startTime为止
+	for pageNo := 1; pageNo <= historyMaxPages; pageNo++ {
+		url := fmt.Sprintf("https://api.bitget.com/api/v2/mix/market/history-fund-rate?symbol=%s&productType=USDT-FUTURES&pageSize=%d&pageNo=%d",
+			symbol, pageSize, pageNo)
+
+		resp, err := b.client.Get(url)
+		if err != nil {
+			return nil, fmt.Errorf("请求历史资金费率失败: %v", err)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取历史资金费率响应失败: %v", err)
+		}
+
+		var response struct {
+			Code string `json:"code"`
+			Msg  string `json:"msg"`
+			Data []struct {
+				FundingRate string `json:"fundingRate"`
+				FundingTime string `json:"fundingTime"`
+			} `json:"data"`
+		}
+
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("解析历史资金费率响应失败: %v", err)
+		}
+
+		if response.Code != "00000" {
+			return nil, fmt.Errorf("API返回错误: %s - %s", response.Code, response.Msg)
+		}
+
+		reachedStart := false
+		for _, item := range response.Data {
+			fundingTime := parseInt64(item.FundingTime)
+			if fundingTime < startTime {
+				reachedStart = true
+			}
+			records = append(records, FundingRecord{
+				Exchange:    b.Name(),
+				Symbol:      symbol,
+				FundingTime: fundingTime,
+				FundingRate: parseFloat(item.FundingRate),
+			})
+		}
+
+		if reachedStart || len(response.Data) < pageSize {
+			break
+		}
+		time.Sleep(historyPageDelay)
+	}
+
+	return filterFundingRecords(records, startTime, endTime), nil
+}
+
+func (b *BitgetExchange) FetchSpotTickers() (map[string]*SpotTicker, error) {
+	resp, err := b.client.Get("https://api.bitget.com/api/v2/spot/market/tickers")
+	if err != nil {
+		return nil, fmt.Errorf("请求现货行情失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取现货行情响应失败: %v", err)
+	}
+
+	var response struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+		Data []struct {
+			Symbol      string `json:"symbol"`
+			LastPr      string `json:"lastPr"`
+			BidPr       string `json:"bidPr"`
+			AskPr       string `json:"askPr"`
+			QuoteVolume string `json:"quoteVolume"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("解析现货行情响应失败: %v", err)
+	}
+
+	if response.Code != "00000" {
+		return nil, fmt.Errorf("API返回错误: %s - %s", response.Code, response.Msg)
+	}
+
+	result := make(map[string]*SpotTicker)
+	for _, item := range response.Data {
+		if !isUSDTContract(item.Symbol) {
+			continue
+		}
+		price := parseFloat(item.LastPr)
+		if price <= 0 {
+			continue
+		}
+		result[item.Symbol] = &SpotTicker{
+			Symbol:         item.Symbol,
+			Price:          price,
+			BidPrice:       parseFloat(item.BidPr),
+			AskPrice:       parseFloat(item.AskPr),
+			QuoteVolume24h: parseFloat(item.QuoteVolume),
+		}
+	}
+
+	return result, nil
+}
+
+func (b *BitgetExchange) FetchNetworkStatus(asset string) ([]NetworkStatus, error) {
+	url := fmt.Sprintf("https://api.bitget.com/api/v2/spot/public/coins?coin=%s", asset)
+
+	resp, err := b.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("请求充提状态失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取充提状态响应失败: %v", err)
+	}
+
+	var response struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+		Data []struct {
+			Coin   string `json:"coin"`
+			Chains []struct {
+				Chain          string `json:"chain"`
+				Withdrawable   string `json:"withdrawable"`
+				Rechargeable   string `json:"rechargeable"`
+				WithdrawFee    string `json:"withdrawFee"`
+				DepositConfirm string `json:"depositConfirm"`
+			} `json:"chains"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("解析充提状态响应失败: %v", err)
+	}
+
+	if response.Code != "00000" {
+		return nil, fmt.Errorf("API返回错误: %s - %s", response.Code, response.Msg)
+	}
+
+	var result []NetworkStatus
+	for _, coin := range response.Data {
+		for _, chain := range coin.Chains {
+			result = append(result, NetworkStatus{
+				Asset:           asset,
+				Network:         normalizeNetwork(chain.Chain),
+				DepositEnabled:  chain.Rechargeable == "true",
+				WithdrawEnabled: chain.Withdrawable == "true",
+				WithdrawFee:     parseFloat(chain.WithdrawFee),
+				Confirmations:   int(parseInt64(chain.DepositConfirm)),
+			})
 		}
 	}
 
@@ -294,3 +621,36 @@ func isUSDTContract(symbol string) bool {
 	
 	return false
 }
+
+// FetchFeeRate 查询账户的合约手续费率，按BTCUSDT的费率代表账户等级
+func (b *BitgetExchange) FetchFeeRate(client *AuthClient) (FeeRate, error) {
+	params := url.Values{}
+	params.Set("symbol", feeReferenceSymbol)
+	params.Set("businessType", "mix")
+
+	var response struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+		Data struct {
+			MakerFeeRate string `json:"makerFeeRate"`
+			TakerFeeRate string `json:"takerFeeRate"`
+		} `json:"data"`
+	}
+
+	if err := client.Do(http.MethodGet, "/api/v2/common/trade-rate", params, nil, &response); err != nil {
+		return FeeRate{}, fmt.Errorf("请求手续费率失败: %v", err)
+	}
+
+	if response.Code != "00000" {
+		return FeeRate{}, fmt.Errorf("API返回错误: %s", response.Msg)
+	}
+
+	if response.Data.TakerFeeRate == "" {
+		return FeeRate{}, fmt.Errorf("响应中没有手续费率")
+	}
+
+	return FeeRate{
+		Maker: parseFloat(response.Data.MakerFeeRate),
+		Taker: parseFloat(response.Data.TakerFeeRate),
+	}, nil
+}
@@ -5,20 +5,31 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"sync"
 	"time"
 )
 
 type BybitExchange struct {
-	client         *http.Client
-	tradingSymbols map[string]bool // symbol -> is trading
-	mu             sync.RWMutex
+	client           *http.Client
+	tradingSymbols   map[string]bool    // symbol -> is trading
+	fundingIntervals map[string]float64 // symbol -> interval in hours
+	fundingLimits    map[string]FundingRateLimit
+	intervalChanges  *IntervalTracker
+	statusTracker   *ContractStatusTracker
+	schemaChecks     *SchemaTracker
+	mu               sync.RWMutex
 }
 
-func NewBybitExchange() *BybitExchange {
+func NewBybitExchange(client *http.Client) *BybitExchange {
 	return &BybitExchange{
-		client:         &http.Client{Timeout: 10 * time.Second},
-		tradingSymbols: make(map[string]bool),
+		client:           client,
+		tradingSymbols:   make(map[string]bool),
+		fundingIntervals: make(map[string]float64),
+		fundingLimits:    make(map[string]FundingRateLimit),
+		intervalChanges:  NewIntervalTracker("Bybit"),
+		statusTracker:    NewContractStatusTracker("Bybit"),
+		schemaChecks:     NewSchemaTracker("Bybit"),
 	}
 }
 
@@ -26,6 +37,11 @@ func (b *BybitExchange) Name() string {
 	return "Bybit"
 }
 
+// tickers的fundingRate为本期实时预估费率，结算前会持续变化
+func (b *BybitExchange) FundingRatePredicted() bool {
+	return true
+}
+
 func (b *BybitExchange) Initialize() error {
 	return nil
 }
@@ -43,8 +59,26 @@ func (b *BybitExchange) isTrading(symbol string) bool {
 	return ok && trading
 }
 
+// ContractEvents 取出合约上线、下架和状态变化事件
+func (b *BybitExchange) ContractEvents() []ContractEvent {
+	return b.statusTracker.Drain()
+}
+
+// IntervalChanges 取出尚未通知的结算周期变化
+func (b *BybitExchange) IntervalChanges() []IntervalChange {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.intervalChanges.Drain()
+}
+
+// SchemaChecks 取出每次获取的必需字段检查结果
+func (b *BybitExchange) SchemaChecks() []SchemaCheck {
+	return b.schemaChecks.Drain()
+}
+
 func (b *BybitExchange) UpdateContractStatus() error {
-	url := "https://api.bybit.com/v5/market/instruments-info?category=linear"
+	url := "https://api.bybit.com/v5/market/instruments-info?category=linear&limit=1000"
 	
 	resp, err := b.client.Get(url)
 	if err != nil {
@@ -62,8 +96,10 @@ func (b *BybitExchange) UpdateContractStatus() error {
 		RetMsg  string `json:"retMsg"`
 		Result  struct {
 			List []struct {
-				Symbol string `json:"symbol"`
-				Status string `json:"status"`
+				Symbol           string `json:"symbol"`
+				Status           string `json:"status"`
+				UpperFundingRate string `json:"upperFundingRate"` // 资金费率上限
+				LowerFundingRate string `json:"lowerFundingRate"` // 资金费率下限
 			} `json:"list"`
 		} `json:"result"`
 	}
@@ -76,12 +112,35 @@ func (b *BybitExchange) UpdateContractStatus() error {
 		return fmt.Errorf("API返回错误: %s", response.RetMsg)
 	}
 
+	tradingSymbols := make(map[string]bool)
+	statuses := make(map[string]ContractStatus)
+
 	b.mu.Lock()
-	defer b.mu.Unlock()
-	
 	for _, item := range response.Result.List {
-		b.tradingSymbols[item.Symbol] = (item.Status == "Trading")
+		tradingSymbols[item.Symbol] = (item.Status == "Trading")
+		b.fundingLimits[item.Symbol] = FundingRateLimit{
+			Cap:   parseFloat(item.UpperFundingRate),
+			Floor: parseFloat(item.LowerFundingRate),
+		}
+
+		if len(item.Symbol) < 4 || item.Symbol[len(item.Symbol)-4:] != "USDT" {
+			continue
+		}
+		switch item.Status {
+		case "Trading":
+			statuses[item.Symbol] = ContractStatusTrading
+		case "PreLaunch":
+			statuses[item.Symbol] = ContractStatusPending
+		case "Delivering":
+			statuses[item.Symbol] = ContractStatusDelisting
+		default:
+			statuses[item.Symbol] = ContractStatusSuspended
+		}
 	}
+	b.tradingSymbols = tradingSymbols
+	b.mu.Unlock()
+
+	b.statusTracker.Update(statuses)
 
 	return nil
 }
@@ -108,10 +167,15 @@ func (b *BybitExchange) FetchFundingRates() (map[string]*ContractData, error) {
 			List     []struct {
 				Symbol              string `json:"symbol"`
 				LastPrice           string `json:"lastPrice"`
+				MarkPrice           string `json:"markPrice"`
+				IndexPrice          string `json:"indexPrice"`
+				Bid1Price           string `json:"bid1Price"`
+				Ask1Price           string `json:"ask1Price"`
 				FundingRate         string `json:"fundingRate"`
 				NextFundingTime     string `json:"nextFundingTime"`
 				FundingIntervalHour string `json:"fundingIntervalHour"`
-				Turnover24h         string `json:"turnover24h"` // 24h成交额
+				Turnover24h         string `json:"turnover24h"`       // 24h成交额
+				OpenInterestValue   string `json:"openInterestValue"` // 持仓价值（USDT）
 			} `json:"list"`
 		} `json:"result"`
 	}
@@ -125,6 +189,7 @@ func (b *BybitExchange) FetchFundingRates() (map[string]*ContractData, error) {
 	}
 
 	result := make(map[string]*ContractData)
+	check := b.schemaChecks.Begin(SchemaEndpointFunding)
 	
 	for _, item := range response.Result.List {
 		// 只处理USDT合约
@@ -138,16 +203,18 @@ func (b *BybitExchange) FetchFundingRates() (map[string]*ContractData, error) {
 		}
 
 		price := parseFloat(item.LastPrice)
+
+		check.Item()
+		check.Require("lastPrice", price > 0)
+		check.Require("markPrice", parseFloat(item.MarkPrice) > 0)
+		check.Require("fundingRate", item.FundingRate != "")
+		check.Require("nextFundingTime", validNextFundingTime(parseInt64(item.NextFundingTime)))
+		check.Require("fundingIntervalHour", parseFloat(item.FundingIntervalHour) > 0)
+
 		if price <= 0 {
 			continue
 		}
-		
-		// 过滤24h交易额小于100万的合约
-		turnover24h := parseFloat(item.Turnover24h)
-		if turnover24h < 1000000 {
-			continue
-		}
-		
+
 		fundingRate := parseFloat(item.FundingRate)
 		intervalHour := parseFloat(item.FundingIntervalHour)
 		
@@ -158,13 +225,356 @@ func (b *BybitExchange) FetchFundingRates() (map[string]*ContractData, error) {
 		// 转换为4小时费率
 		fundingRate4h := fundingRate * (4.0 / intervalHour)
 		
-		result[item.Symbol] = &ContractData{
+		contract := &ContractData{
 			Symbol:              item.Symbol,
 			Price:               price,
+			MarkPrice:           parseFloat(item.MarkPrice),
+			IndexPrice:          parseFloat(item.IndexPrice),
+			BidPrice:            parseFloat(item.Bid1Price),
+			AskPrice:            parseFloat(item.Ask1Price),
 			FundingRate:         fundingRate,
 			FundingIntervalHour: intervalHour,
 			FundingRate4h:       fundingRate4h,
 			NextFundingTime:     parseInt64(item.NextFundingTime),
+
+			FundingRatePredicted: b.FundingRatePredicted(),
+			QuoteVolume24h:       parseFloat(item.Turnover24h),
+			OpenInterestUSDT:     parseFloat(item.OpenInterestValue),
+		}
+
+		b.mu.Lock()
+		if parseFloat(item.FundingIntervalHour) > 0 {
+			updateFundingInterval(b.fundingIntervals, b.intervalChanges, item.Symbol, intervalHour)
+		}
+		applyFundingLimit(contract, b.fundingLimits)
+		applyIntervalChange(contract, b.intervalChanges)
+		b.mu.Unlock()
+
+		result[item.Symbol] = contract
+	}
+
+	b.schemaChecks.Record(check)
+
+	return result, nil
+}
+
+func (b *BybitExchange) FetchOrderBook(symbol string, limit int) (*OrderBook, error) {
+	url := fmt.Sprintf("https://api.bybit.com/v5/market/orderbook?category=linear&symbol=%s&limit=%d", symbol, limit)
+
+	resp, err := b.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("请求订单簿失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取订单簿响应失败: %v", err)
+	}
+
+	var response struct {
+		RetCode int    `json:"retCode"`
+		RetMsg  string `json:"retMsg"`
+		Result  struct {
+			Bids [][]interface{} `json:"b"`
+			Asks [][]interface{} `json:"a"`
+		} `json:"result"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("解析订单簿响应失败: %v", err)
+	}
+
+	if response.RetCode != 0 {
+		return nil, fmt.Errorf("API返回错误: %s", response.RetMsg)
+	}
+
+	return &OrderBook{
+		Symbol: symbol,
+		Bids:   parseBookLevels(response.Result.Bids, 1),
+		Asks:   parseBookLevels(response.Result.Asks, 1),
+	}, nil
+}
+
+func (b *BybitExchange) FetchServerTime() (int64, error) {
+	resp, err := b.client.Get("https://api.bybit.com/v5/market/time")
+	if err != nil {
+		return 0, fmt.Errorf("请求服务器时间失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("读取服务器时间响应失败: %v", err)
+	}
+
+	var response struct {
+		RetCode int    `json:"retCode"`
+		RetMsg  string `json:"retMsg"`
+		Result  struct {
+			TimeNano string `json:"timeNano"`
+		} `json:"result"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, fmt.Errorf("解析服务器时间响应失败: %v", err)
+	}
+
+	if response.RetCode != 0 {
+		return 0, fmt.Errorf("API返回错误: %s", response.RetMsg)
+	}
+
+	return parseInt64(response.Result.TimeNano) / 1000000, nil
+}
+
+func (b *BybitExchange) FetchFundingHistory(symbol string, startTime, endTime int64) ([]FundingRecord, error) {
+	const limit = 200
+	var records []FundingRecord
+
+	// 结果按时间倒序，以最早一条的时间作为下一页的终点
+	to := endTime
+	for page := 0; page < historyMaxPages && to >= startTime; page++ {
+		url := fmt.Sprintf("https://api.bybit.com/v5/market/funding/history?category=linear&symbol=%s&startTime=%d&endTime=%d&limit=%d",
+			symbol, startTime, to, limit)
+
+		resp, err := b.client.Get(url)
+		if err != nil {
+			return nil, fmt.Errorf("请求历史资金费率失败: %v", err)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取历史资金费率响应失败: %v", err)
+		}
+
+		var response struct {
+			RetCode int    `json:"retCode"`
+			RetMsg  string `json:"retMsg"`
+			Result  struct {
+				List []struct {
+					FundingRate          string `json:"fundingRate"`
+					FundingRateTimestamp string `json:"fundingRateTimestamp"`
+				} `json:"list"`
+			} `json:"result"`
+		}
+
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("解析历史资金费率响应失败: %v", err)
+		}
+
+		if response.RetCode != 0 {
+			return nil, fmt.Errorf("API返回错误: %s", response.RetMsg)
+		}
+
+		list := response.Result.List
+		for _, item := range list {
+			records = append(records, FundingRecord{
+				Exchange:    b.Name(),
+				Symbol:      symbol,
+				FundingTime: parseInt64(item.FundingRateTimestamp),
+				FundingRate: parseFloat(item.FundingRate),
+			})
+		}
+
+		if len(list) < limit {
+			break
+		}
+		to = parseInt64(list[len(list)-1].FundingRateTimestamp) - 1
+		time.Sleep(historyPageDelay)
+	}
+
+	return records, nil
+}
+
+func (b *BybitExchange) FetchSpotTickers() (map[string]*SpotTicker, error) {
+	resp, err := b.client.Get("https://api.bybit.com/v5/market/tickers?category=spot")
+	if err != nil {
+		return nil, fmt.Errorf("请求现货行情失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取现货行情响应失败: %v", err)
+	}
+
+	var response struct {
+		RetCode int    `json:"retCode"`
+		RetMsg  string `json:"retMsg"`
+		Result  struct {
+			List []struct {
+				Symbol      string `json:"symbol"`
+				LastPrice   string `json:"lastPrice"`
+				Bid1Price   string `json:"bid1Price"`
+				Ask1Price   string `json:"ask1Price"`
+				Turnover24h string `json:"turnover24h"`
+			} `json:"list"`
+		} `json:"result"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("解析现货行情响应失败: %v", err)
+	}
+
+	if response.RetCode != 0 {
+		return nil, fmt.Errorf("API返回错误: %s", response.RetMsg)
+	}
+
+	result := make(map[string]*SpotTicker)
+	for _, item := range response.Result.List {
+		if len(item.Symbol) < 4 || item.Symbol[len(item.Symbol)-4:] != "USDT" {
+			continue
+		}
+		price := parseFloat(item.LastPrice)
+		if price <= 0 {
+			continue
+		}
+		result[item.Symbol] = &SpotTicker{
+			Symbol:         item.Symbol,
+			Price:          price,
+			BidPrice:       parseFloat(item.Bid1Price),
+			AskPrice:       parseFloat(item.Ask1Price),
+			QuoteVolume24h: parseFloat(item.Turnover24h),
+		}
+	}
+
+	return result, nil
+}
+
+func (b *BybitExchange) FetchBorrowRates() (map[string]*BorrowRate, error) {
+	resp, err := b.client.Get("https://api.bybit.com/v5/spot-margin-trade/data")
+	if err != nil {
+		return nil, fmt.Errorf("请求借币利率失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取借币利率响应失败: %v", err)
+	}
+
+	var response struct {
+		RetCode int    `json:"retCode"`
+		RetMsg  string `json:"retMsg"`
+		Result  struct {
+			VipCoinList []struct {
+				VipLevel string `json:"vipLevel"`
+				List     []struct {
+					Currency         string `json:"currency"`
+					HourlyBorrowRate string `json:"hourlyBorrowRate"`
+					Borrowable       bool   `json:"borrowable"`
+				} `json:"list"`
+			} `json:"vipCoinList"`
+		} `json:"result"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("解析借币利率响应失败: %v", err)
+	}
+
+	if response.RetCode != 0 {
+		return nil, fmt.Errorf("API返回错误: %s", response.RetMsg)
+	}
+
+	// 按普通用户（No VIP）的利率计算
+	result := make(map[string]*BorrowRate)
+	for _, vip := range response.Result.VipCoinList {
+		if vip.VipLevel != "No VIP" {
+			continue
+		}
+		for _, item := range vip.List {
+			result[item.Currency] = &BorrowRate{
+				Asset:      item.Currency,
+				HourlyRate: parseFloat(item.HourlyBorrowRate),
+				Borrowable: item.Borrowable,
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// FetchFeeRate 查询账户的USDT永续合约手续费率，按BTCUSDT的费率代表账户等级
+func (b *BybitExchange) FetchFeeRate(client *AuthClient) (FeeRate, error) {
+	params := url.Values{}
+	params.Set("category", "linear")
+	params.Set("symbol", feeReferenceSymbol)
+
+	var response struct {
+		RetCode int    `json:"retCode"`
+		RetMsg  string `json:"retMsg"`
+		Result  struct {
+			List []struct {
+				Symbol       string `json:"symbol"`
+				MakerFeeRate string `json:"makerFeeRate"`
+				TakerFeeRate string `json:"takerFeeRate"`
+			} `json:"list"`
+		} `json:"result"`
+	}
+
+	if err := client.Do(http.MethodGet, "/v5/account/fee-rate", params, nil, &response); err != nil {
+		return FeeRate{}, fmt.Errorf("请求手续费率失败: %v", err)
+	}
+
+	if response.RetCode != 0 {
+		return FeeRate{}, fmt.Errorf("API返回错误: %s", response.RetMsg)
+	}
+
+	if len(response.Result.List) == 0 {
+		return FeeRate{}, fmt.Errorf("响应中没有手续费率")
+	}
+
+	return FeeRate{
+		Maker: parseFloat(response.Result.List[0].MakerFeeRate),
+		Taker: parseFloat(response.Result.List[0].TakerFeeRate),
+	}, nil
+}
+
+// FetchAuthNetworkStatus 查询币种各链的充提状态，Bybit的币种信息接口需要签名
+func (b *BybitExchange) FetchAuthNetworkStatus(client *AuthClient, asset string) ([]NetworkStatus, error) {
+	params := url.Values{}
+	params.Set("coin", asset)
+
+	var response struct {
+		RetCode int    `json:"retCode"`
+		RetMsg  string `json:"retMsg"`
+		Result  struct {
+			Rows []struct {
+				Coin   string `json:"coin"`
+				Chains []struct {
+					Chain         string `json:"chain"`
+					ChainDeposit  string `json:"chainDeposit"`  // 1可充值
+					ChainWithdraw string `json:"chainWithdraw"` // 1可提币
+					WithdrawFee   string `json:"withdrawFee"`
+					Confirmation  string `json:"confirmation"`
+				} `json:"chains"`
+			} `json:"rows"`
+		} `json:"result"`
+	}
+
+	if err := client.Do(http.MethodGet, "/v5/asset/coin/query-info", params, nil, &response); err != nil {
+		return nil, fmt.Errorf("请求充提状态失败: %v", err)
+	}
+
+	if response.RetCode != 0 {
+		return nil, fmt.Errorf("API返回错误: %s", response.RetMsg)
+	}
+
+	var result []NetworkStatus
+	for _, row := range response.Result.Rows {
+		if row.Coin != asset {
+			continue
+		}
+		for _, chain := range row.Chains {
+			result = append(result, NetworkStatus{
+				Asset:           asset,
+				Network:         normalizeNetwork(chain.Chain),
+				DepositEnabled:  chain.ChainDeposit == "1",
+				WithdrawEnabled: chain.ChainWithdraw == "1",
+				WithdrawFee:     parseWithdrawFee(chain.WithdrawFee),
+				Confirmations:   int(parseInt64(chain.Confirmation)),
+			})
 		}
 	}
 
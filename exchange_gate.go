@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"sync"
 	"time"
 )
@@ -14,15 +15,25 @@ type GateExchange struct {
 	fundingIntervals  map[string]float64 // symbol -> interval in hours
 	nextFundingTimes  map[string]int64   // symbol -> next funding time (milliseconds)
 	tradingSymbols    map[string]bool    // symbol -> is trading
+	contractSizes     map[string]float64 // symbol -> 每张合约对应的币数量
+	fundingLimits     map[string]FundingRateLimit
+	intervalChanges   *IntervalTracker
+	statusTracker    *ContractStatusTracker
+	schemaChecks      *SchemaTracker
 	mu                sync.RWMutex
 }
 
-func NewGateExchange() *GateExchange {
+func NewGateExchange(client *http.Client) *GateExchange {
 	return &GateExchange{
-		client:           &http.Client{Timeout: 10 * time.Second},
+		client:           client,
 		fundingIntervals: make(map[string]float64),
 		nextFundingTimes: make(map[string]int64),
 		tradingSymbols:   make(map[string]bool),
+		contractSizes:    make(map[string]float64),
+		fundingLimits:    make(map[string]FundingRateLimit),
+		intervalChanges:  NewIntervalTracker("Gate"),
+		statusTracker:    NewContractStatusTracker("Gate"),
+		schemaChecks:     NewSchemaTracker("Gate"),
 	}
 }
 
@@ -30,6 +41,11 @@ func (g *GateExchange) Name() string {
 	return "Gate"
 }
 
+// tickers的funding_rate在上一次结算时确定，本期内不再变化
+func (g *GateExchange) FundingRatePredicted() bool {
+	return false
+}
+
 func (g *GateExchange) Initialize() error {
 	return nil
 }
@@ -53,6 +69,10 @@ func (g *GateExchange) UpdateFundingIntervals() error {
 		Name              string `json:"name"`
 		FundingInterval   int    `json:"funding_interval"`   // 单位：秒
 		FundingNextApply  int64  `json:"funding_next_apply"` // 下次结算时间戳（秒）
+		QuantoMultiplier  string `json:"quanto_multiplier"`  // 每张合约对应的币数量
+		FundingCapRatio   string `json:"funding_cap_ratio"`  // 资金费率上限系数
+		LeverageMax       string `json:"leverage_max"`
+		MaintenanceRate   string `json:"maintenance_rate"`
 		InDelisting       bool   `json:"in_delisting"`
 		Status            string `json:"status"`
 	}
@@ -61,10 +81,20 @@ func (g *GateExchange) UpdateFundingIntervals() error {
 		return fmt.Errorf("解析响应失败: %v", err)
 	}
 
-	g.mu.Lock()
-	defer g.mu.Unlock()
+	tradingSymbols := make(map[string]bool)
+	statuses := make(map[string]ContractStatus)
+
+	check := g.schemaChecks.Begin(SchemaEndpointInterval)
 
+	g.mu.Lock()
 	for _, contract := range contracts {
+		if contract.Status == "trading" {
+			check.Item()
+			check.Require("funding_interval", contract.FundingInterval > 0)
+			check.Require("funding_next_apply", validNextFundingTime(contract.FundingNextApply*1000))
+			check.Require("quanto_multiplier", parseFloat(contract.QuantoMultiplier) > 0)
+		}
+
 		// Gate的symbol格式如 BTC_USDT，转换为 BTCUSDT
 		symbol := contract.Name
 		if len(symbol) > 5 && symbol[len(symbol)-5:] == "_USDT" {
@@ -73,7 +103,17 @@ func (g *GateExchange) UpdateFundingIntervals() error {
 		
 		if contract.FundingInterval > 0 {
 			intervalHour := float64(contract.FundingInterval) / 3600.0
-			g.fundingIntervals[symbol] = intervalHour
+			updateFundingInterval(g.fundingIntervals, g.intervalChanges, symbol, intervalHour)
+		}
+
+		// 费率上限 = (1/最大杠杆 - 维持保证金率) × funding_cap_ratio，下限对称
+		leverageMax := parseFloat(contract.LeverageMax)
+		capRatio := parseFloat(contract.FundingCapRatio)
+		if leverageMax > 0 && capRatio > 0 {
+			fundingCap := (1/leverageMax - parseFloat(contract.MaintenanceRate)) * capRatio
+			if fundingCap > 0 {
+				g.fundingLimits[symbol] = FundingRateLimit{Cap: fundingCap, Floor: -fundingCap}
+			}
 		}
 		
 		if contract.FundingNextApply > 0 {
@@ -81,9 +121,27 @@ func (g *GateExchange) UpdateFundingIntervals() error {
 			g.nextFundingTimes[symbol] = contract.FundingNextApply * 1000
 		}
 		
+		if multiplier := parseFloat(contract.QuantoMultiplier); multiplier > 0 {
+			g.contractSizes[symbol] = multiplier
+		}
+
 		// 更新合约状态
-		g.tradingSymbols[symbol] = (contract.Status == "trading" && !contract.InDelisting)
+		tradingSymbols[symbol] = (contract.Status == "trading" && !contract.InDelisting)
+
+		switch {
+		case contract.InDelisting:
+			statuses[symbol] = ContractStatusDelisting
+		case contract.Status == "trading":
+			statuses[symbol] = ContractStatusTrading
+		default:
+			statuses[symbol] = ContractStatusSuspended
+		}
 	}
+	g.tradingSymbols = tradingSymbols
+	g.mu.Unlock()
+
+	g.schemaChecks.Record(check)
+	g.statusTracker.Update(statuses)
 
 	return nil
 }
@@ -108,6 +166,16 @@ func (g *GateExchange) getNextFundingTime(symbol string) int64 {
 	return 0
 }
 
+func (g *GateExchange) getContractSize(symbol string) float64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if size, ok := g.contractSizes[symbol]; ok {
+		return size
+	}
+	return 1.0
+}
+
 func (g *GateExchange) isTrading(symbol string) bool {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
@@ -116,6 +184,24 @@ func (g *GateExchange) isTrading(symbol string) bool {
 	return ok && trading
 }
 
+// ContractEvents 取出合约上线、下架和状态变化事件
+func (g *GateExchange) ContractEvents() []ContractEvent {
+	return g.statusTracker.Drain()
+}
+
+// IntervalChanges 取出尚未通知的结算周期变化
+func (g *GateExchange) IntervalChanges() []IntervalChange {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.intervalChanges.Drain()
+}
+
+// SchemaChecks 取出每次获取的必需字段检查结果
+func (g *GateExchange) SchemaChecks() []SchemaCheck {
+	return g.schemaChecks.Drain()
+}
+
 func (g *GateExchange) UpdateContractStatus() error {
 	// UpdateFundingIntervals 已经获取了合约状态，这里不需要重复
 	return nil
@@ -139,8 +225,13 @@ func (g *GateExchange) FetchFundingRates() (map[string]*ContractData, error) {
 	var tickers []struct {
 		Contract        string `json:"contract"`
 		Last            string `json:"last"`
+		MarkPrice       string `json:"mark_price"`
+		IndexPrice      string `json:"index_price"`
+		HighestBid      string `json:"highest_bid"`
+		LowestAsk       string `json:"lowest_ask"`
 		FundingRate     string `json:"funding_rate"`
 		Volume24hQuote  string `json:"volume_24h_quote"` // 24h成交额（报价货币）
+		TotalSize       string `json:"total_size"`       // 持仓量（张）
 	}
 
 	if err := json.Unmarshal(body, &tickers); err != nil {
@@ -148,6 +239,7 @@ func (g *GateExchange) FetchFundingRates() (map[string]*ContractData, error) {
 	}
 
 	result := make(map[string]*ContractData)
+	check := g.schemaChecks.Begin(SchemaEndpointFunding)
 	
 	for _, ticker := range tickers {
 		// Gate的symbol格式如 BTC_USDT，转换为 BTCUSDT
@@ -167,16 +259,17 @@ func (g *GateExchange) FetchFundingRates() (map[string]*ContractData, error) {
 		}
 
 		price := parseFloat(ticker.Last)
+
+		// 结算周期和下次结算时间来自合约信息接口，在结算周期的检查中统计
+		check.Item()
+		check.Require("last", price > 0)
+		check.Require("mark_price", parseFloat(ticker.MarkPrice) > 0)
+		check.Require("funding_rate", ticker.FundingRate != "")
+
 		if price <= 0 {
 			continue
 		}
-		
-		// 过滤24h交易额小于100万的合约
-		volume24hQuote := parseFloat(ticker.Volume24hQuote)
-		if volume24hQuote < 1000000 {
-			continue
-		}
-		
+
 		fundingRate := parseFloat(ticker.FundingRate)
 
 		intervalHour := g.getFundingInterval(symbol)
@@ -185,15 +278,307 @@ func (g *GateExchange) FetchFundingRates() (map[string]*ContractData, error) {
 		// 转换为4小时费率
 		fundingRate4h := fundingRate * (4.0 / intervalHour)
 
-		result[symbol] = &ContractData{
+		contract := &ContractData{
 			Symbol:              symbol,
 			Price:               price,
+			MarkPrice:           parseFloat(ticker.MarkPrice),
+			IndexPrice:          parseFloat(ticker.IndexPrice),
+			BidPrice:            parseFloat(ticker.HighestBid),
+			AskPrice:            parseFloat(ticker.LowestAsk),
 			FundingRate:         fundingRate,
 			FundingIntervalHour: intervalHour,
 			FundingRate4h:       fundingRate4h,
 			NextFundingTime:     nextFundingTime,
+
+			FundingRatePredicted: g.FundingRatePredicted(),
+			QuoteVolume24h:       parseFloat(ticker.Volume24hQuote),
 		}
+
+		// 持仓价值 = 张数 × 每张币数量 × 标记价格
+		if totalSize := parseFloat(ticker.TotalSize); totalSize > 0 {
+			contract.OpenInterestUSDT = totalSize * g.getContractSize(symbol) * contract.PriceFor(PriceSourceMark)
+		}
+
+		g.mu.RLock()
+		applyFundingLimit(contract, g.fundingLimits)
+		applyIntervalChange(contract, g.intervalChanges)
+		g.mu.RUnlock()
+
+		result[symbol] = contract
 	}
 
+	g.schemaChecks.Record(check)
+
 	return result, nil
 }
+
+func (g *GateExchange) FetchOrderBook(symbol string, limit int) (*OrderBook, error) {
+	url := fmt.Sprintf("https://api.gateio.ws/api/v4/futures/usdt/order_book?contract=%s&limit=%d", toUnderscoreSymbol(symbol), limit)
+
+	resp, err := g.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("请求订单簿失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取订单簿响应失败: %v", err)
+	}
+
+	type gateLevel struct {
+		Price string      `json:"p"`
+		Size  interface{} `json:"s"` // 单位：张
+	}
+
+	var depth struct {
+		Bids []gateLevel `json:"bids"`
+		Asks []gateLevel `json:"asks"`
+	}
+
+	if err := json.Unmarshal(body, &depth); err != nil {
+		return nil, fmt.Errorf("解析订单簿响应失败: %v", err)
+	}
+
+	contractSize := g.getContractSize(symbol)
+	toLevels := func(items []gateLevel) []OrderBookLevel {
+		raw := make([][]interface{}, 0, len(items))
+		for _, item := range items {
+			raw = append(raw, []interface{}{item.Price, item.Size})
+		}
+		return parseBookLevels(raw, contractSize)
+	}
+
+	return &OrderBook{
+		Symbol: symbol,
+		Bids:   toLevels(depth.Bids),
+		Asks:   toLevels(depth.Asks),
+	}, nil
+}
+
+func (g *GateExchange) FetchServerTime() (int64, error) {
+	resp, err := g.client.Get("https://api.gateio.ws/api/v4/spot/time")
+	if err != nil {
+		return 0, fmt.Errorf("请求服务器时间失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("读取服务器时间响应失败: %v", err)
+	}
+
+	var response struct {
+		ServerTime int64 `json:"server_time"` // 毫秒
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, fmt.Errorf("解析服务器时间响应失败: %v", err)
+	}
+
+	return response.ServerTime, nil
+}
+
+func (g *GateExchange) FetchFundingHistory(symbol string, startTime, endTime int64) ([]FundingRecord, error) {
+	const limit = 1000
+	var records []FundingRecord
+
+	// Gate时间参数单位为秒，结果按时间倒序，以最早一条的时间作为下一页的终点
+	from := startTime / 1000
+	to := endTime / 1000
+	for page := 0; page < historyMaxPages && to >= from; page++ {
+		url := fmt.Sprintf("https://api.gateio.ws/api/v4/futures/usdt/funding_rate?contract=%s&limit=%d&from=%d&to=%d",
+			toUnderscoreSymbol(symbol), limit, from, to)
+
+		resp, err := g.client.Get(url)
+		if err != nil {
+			return nil, fmt.Errorf("请求历史资金费率失败: %v", err)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取历史资金费率响应失败: %v", err)
+		}
+
+		var items []struct {
+			T int64  `json:"t"` // 秒
+			R string `json:"r"`
+		}
+
+		if err := json.Unmarshal(body, &items); err != nil {
+			return nil, fmt.Errorf("解析历史资金费率响应失败: %v", err)
+		}
+
+		oldest := to
+		for _, item := range items {
+			records = append(records, FundingRecord{
+				Exchange:    g.Name(),
+				Symbol:      symbol,
+				FundingTime: item.T * 1000,
+				FundingRate: parseFloat(item.R),
+			})
+			if item.T < oldest {
+				oldest = item.T
+			}
+		}
+
+		if len(items) < limit {
+			break
+		}
+		to = oldest - 1
+		time.Sleep(historyPageDelay)
+	}
+
+	return filterFundingRecords(records, startTime, endTime), nil
+}
+
+func (g *GateExchange) FetchSpotTickers() (map[string]*SpotTicker, error) {
+	resp, err := g.client.Get("https://api.gateio.ws/api/v4/spot/tickers")
+	if err != nil {
+		return nil, fmt.Errorf("请求现货行情失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取现货行情响应失败: %v", err)
+	}
+
+	var tickers []struct {
+		CurrencyPair string `json:"currency_pair"`
+		Last         string `json:"last"`
+		HighestBid   string `json:"highest_bid"`
+		LowestAsk    string `json:"lowest_ask"`
+		QuoteVolume  string `json:"quote_volume"`
+	}
+
+	if err := json.Unmarshal(body, &tickers); err != nil {
+		return nil, fmt.Errorf("解析现货行情响应失败: %v", err)
+	}
+
+	result := make(map[string]*SpotTicker)
+	for _, ticker := range tickers {
+		// BTC_USDT -> BTCUSDT
+		symbol := ticker.CurrencyPair
+		if len(symbol) <= 5 || symbol[len(symbol)-5:] != "_USDT" {
+			continue
+		}
+		symbol = symbol[:len(symbol)-5] + "USDT"
+
+		price := parseFloat(ticker.Last)
+		if price <= 0 {
+			continue
+		}
+		result[symbol] = &SpotTicker{
+			Symbol:         symbol,
+			Price:          price,
+			BidPrice:       parseFloat(ticker.HighestBid),
+			AskPrice:       parseFloat(ticker.LowestAsk),
+			QuoteVolume24h: parseFloat(ticker.QuoteVolume),
+		}
+	}
+
+	return result, nil
+}
+
+func (g *GateExchange) FetchBorrowRates() (map[string]*BorrowRate, error) {
+	resp, err := g.client.Get("https://api.gateio.ws/api/v4/margin/cross/currencies")
+	if err != nil {
+		return nil, fmt.Errorf("请求借币利率失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取借币利率响应失败: %v", err)
+	}
+
+	var currencies []struct {
+		Name     string `json:"name"`
+		Rate     string `json:"rate"` // 小时利率
+		Loanable bool   `json:"loanable"`
+		Status   int    `json:"status"` // 1 启用
+	}
+
+	if err := json.Unmarshal(body, &currencies); err != nil {
+		return nil, fmt.Errorf("解析借币利率响应失败: %v", err)
+	}
+
+	result := make(map[string]*BorrowRate)
+	for _, currency := range currencies {
+		result[currency.Name] = &BorrowRate{
+			Asset:      currency.Name,
+			HourlyRate: parseFloat(currency.Rate),
+			Borrowable: currency.Loanable && currency.Status == 1,
+		}
+	}
+
+	return result, nil
+}
+
+func (g *GateExchange) FetchNetworkStatus(asset string) ([]NetworkStatus, error) {
+	url := fmt.Sprintf("https://api.gateio.ws/api/v4/wallet/currency_chains?currency=%s", asset)
+
+	resp, err := g.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("请求充提状态失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取充提状态响应失败: %v", err)
+	}
+
+	var chains []struct {
+		Chain              string `json:"chain"`
+		IsDisabled         int    `json:"is_disabled"`
+		IsDepositDisabled  int    `json:"is_deposit_disabled"`
+		IsWithdrawDisabled int    `json:"is_withdraw_disabled"`
+	}
+
+	if err := json.Unmarshal(body, &chains); err != nil {
+		return nil, fmt.Errorf("解析充提状态响应失败: %v", err)
+	}
+
+	// 公开接口不返回提币手续费和确认数
+	var result []NetworkStatus
+	for _, chain := range chains {
+		result = append(result, NetworkStatus{
+			Asset:           asset,
+			Network:         normalizeNetwork(chain.Chain),
+			DepositEnabled:  chain.IsDisabled == 0 && chain.IsDepositDisabled == 0,
+			WithdrawEnabled: chain.IsDisabled == 0 && chain.IsWithdrawDisabled == 0,
+			WithdrawFee:     -1,
+		})
+	}
+
+	return result, nil
+}
+
+// FetchFeeRate 查询账户的USDT永续合约手续费率，按BTC_USDT的费率代表账户等级
+func (g *GateExchange) FetchFeeRate(client *AuthClient) (FeeRate, error) {
+	contract := toUnderscoreSymbol(feeReferenceSymbol)
+	params := url.Values{}
+	params.Set("contract", contract)
+
+	var response map[string]struct {
+		TakerFee string `json:"taker_fee"`
+		MakerFee string `json:"maker_fee"`
+	}
+
+	if err := client.Do(http.MethodGet, "/futures/usdt/fee", params, nil, &response); err != nil {
+		return FeeRate{}, fmt.Errorf("请求手续费率失败: %v", err)
+	}
+
+	fee, ok := response[contract]
+	if !ok || fee.TakerFee == "" {
+		return FeeRate{}, fmt.Errorf("响应中没有手续费率")
+	}
+
+	return FeeRate{
+		Maker: parseFloat(fee.MakerFee),
+		Taker: parseFloat(fee.TakerFee),
+	}, nil
+}
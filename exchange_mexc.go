@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
 )
@@ -13,14 +15,22 @@ type MEXCExchange struct {
 	client            *http.Client
 	fundingIntervals  map[string]float64 // symbol -> interval in hours
 	tradingSymbols    map[string]bool    // symbol -> is trading
+	contractSizes     map[string]float64 // symbol -> 每张合约对应的币数量
+	intervalChanges   *IntervalTracker
+	statusTracker    *ContractStatusTracker
+	schemaChecks      *SchemaTracker
 	mu                sync.RWMutex
 }
 
-func NewMEXCExchange() *MEXCExchange {
+func NewMEXCExchange(client *http.Client) *MEXCExchange {
 	return &MEXCExchange{
-		client:           &http.Client{Timeout: 10 * time.Second},
+		client:           client,
 		fundingIntervals: make(map[string]float64),
 		tradingSymbols:   make(map[string]bool),
+		contractSizes:    make(map[string]float64),
+		intervalChanges:  NewIntervalTracker("MEXC"),
+		statusTracker:    NewContractStatusTracker("MEXC"),
+		schemaChecks:     NewSchemaTracker("MEXC"),
 	}
 }
 
@@ -28,6 +38,11 @@ func (m *MEXCExchange) Name() string {
 	return "MEXC"
 }
 
+// funding_rate的fundingRate为本期实时预估费率，结算前会持续变化
+func (m *MEXCExchange) FundingRatePredicted() bool {
+	return true
+}
+
 func (m *MEXCExchange) Initialize() error {
 	return nil
 }
@@ -65,6 +80,13 @@ func (m *MEXCExchange) UpdateFundingIntervals() error {
 		return fmt.Errorf("API返回错误，code: %d", response.Code)
 	}
 
+	check := m.schemaChecks.Begin(SchemaEndpointInterval)
+	for _, item := range response.Data {
+		check.Item()
+		check.Require("collectCycle", item.CollectCycle > 0)
+	}
+	m.schemaChecks.Record(check)
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -75,7 +97,7 @@ func (m *MEXCExchange) UpdateFundingIntervals() error {
 			if len(symbol) > 5 && symbol[len(symbol)-5:] == "_USDT" {
 				symbol = symbol[:len(symbol)-5] + "USDT"
 			}
-			m.fundingIntervals[symbol] = float64(item.CollectCycle)
+			updateFundingInterval(m.fundingIntervals, m.intervalChanges, symbol, float64(item.CollectCycle))
 		}
 	}
 
@@ -100,6 +122,34 @@ func (m *MEXCExchange) isTrading(symbol string) bool {
 	return ok && trading
 }
 
+// ContractEvents 取出合约上线、下架和状态变化事件
+func (m *MEXCExchange) ContractEvents() []ContractEvent {
+	return m.statusTracker.Drain()
+}
+
+// IntervalChanges 取出尚未通知的结算周期变化
+func (m *MEXCExchange) IntervalChanges() []IntervalChange {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.intervalChanges.Drain()
+}
+
+// SchemaChecks 取出每次获取的必需字段检查结果
+func (m *MEXCExchange) SchemaChecks() []SchemaCheck {
+	return m.schemaChecks.Drain()
+}
+
+func (m *MEXCExchange) getContractSize(symbol string) float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if size, ok := m.contractSizes[symbol]; ok {
+		return size
+	}
+	return 1.0
+}
+
 func (m *MEXCExchange) UpdateContractStatus() error {
 	url := "https://contract.mexc.com/api/v1/contract/detail"
 	
@@ -118,8 +168,9 @@ func (m *MEXCExchange) UpdateContractStatus() error {
 		Success bool `json:"success"`
 		Code    int  `json:"code"`
 		Data    []struct {
-			Symbol string `json:"symbol"`
-			State  int    `json:"state"`
+			Symbol       string  `json:"symbol"`
+			State        int     `json:"state"`
+			ContractSize float64 `json:"contractSize"` // 每张合约对应的币数量
 		} `json:"data"`
 	}
 
@@ -131,17 +182,38 @@ func (m *MEXCExchange) UpdateContractStatus() error {
 		return fmt.Errorf("API返回错误，code: %d", response.Code)
 	}
 
+	tradingSymbols := make(map[string]bool)
+	statuses := make(map[string]ContractStatus)
+
 	m.mu.Lock()
-	defer m.mu.Unlock()
-	
 	for _, item := range response.Data {
 		// 转换symbol格式
 		symbol := item.Symbol
 		if len(symbol) > 5 && symbol[len(symbol)-5:] == "_USDT" {
 			symbol = symbol[:len(symbol)-5] + "USDT"
 		}
-		m.tradingSymbols[symbol] = (item.State == 0)
+		tradingSymbols[symbol] = (item.State == 0)
+		if item.ContractSize > 0 {
+			m.contractSizes[symbol] = item.ContractSize
+		}
+
+		if !strings.HasSuffix(item.Symbol, "_USDT") {
+			continue
+		}
+		// state: 0 启用，1 交割中，2 交割完成，3 下线，4 暂停
+		switch item.State {
+		case 0:
+			statuses[symbol] = ContractStatusTrading
+		case 1:
+			statuses[symbol] = ContractStatusDelisting
+		default:
+			statuses[symbol] = ContractStatusSuspended
+		}
 	}
+	m.tradingSymbols = tradingSymbols
+	m.mu.Unlock()
+
+	m.statusTracker.Update(statuses)
 
 	return nil
 }
@@ -169,6 +241,8 @@ func (m *MEXCExchange) FetchFundingRates() (map[string]*ContractData, error) {
 			FundingRate     float64 `json:"fundingRate"`
 			CollectCycle    int     `json:"collectCycle"`    // 单位：小时
 			NextSettleTime  int64   `json:"nextSettleTime"`  // 下次结算时间戳（毫秒）
+			MaxFundingRate  float64 `json:"maxFundingRate"`  // 资金费率上限
+			MinFundingRate  float64 `json:"minFundingRate"`  // 资金费率下限
 		} `json:"data"`
 	}
 
@@ -196,9 +270,14 @@ func (m *MEXCExchange) FetchFundingRates() (map[string]*ContractData, error) {
 	var priceResponse struct {
 		Success bool `json:"success"`
 		Data    []struct {
-			Symbol    string  `json:"symbol"`
-			LastPrice float64 `json:"lastPrice"`
-			Amount24  float64 `json:"amount24"` // 24h成交额
+			Symbol     string  `json:"symbol"`
+			LastPrice  float64 `json:"lastPrice"`
+			FairPrice  float64 `json:"fairPrice"` // 标记价格
+			IndexPrice float64 `json:"indexPrice"`
+			Bid1       float64 `json:"bid1"`
+			Ask1       float64 `json:"ask1"`
+			Amount24   float64 `json:"amount24"` // 24h成交额
+			HoldVol    float64 `json:"holdVol"`  // 持仓量（张）
 		} `json:"data"`
 	}
 
@@ -207,20 +286,31 @@ func (m *MEXCExchange) FetchFundingRates() (map[string]*ContractData, error) {
 	}
 
 	type TickerData struct {
-		Price   float64
-		Amount24 float64
+		Price      float64
+		MarkPrice  float64
+		IndexPrice float64
+		BidPrice   float64
+		AskPrice   float64
+		Amount24   float64
+		HoldVol    float64
 	}
 	tickerMap := make(map[string]TickerData)
 	for _, item := range priceResponse.Data {
 		if item.LastPrice > 0 {
 			tickerMap[item.Symbol] = TickerData{
-				Price:   item.LastPrice,
-				Amount24: item.Amount24,
+				Price:      item.LastPrice,
+				MarkPrice:  item.FairPrice,
+				IndexPrice: item.IndexPrice,
+				BidPrice:   item.Bid1,
+				AskPrice:   item.Ask1,
+				Amount24:   item.Amount24,
+				HoldVol:    item.HoldVol,
 			}
 		}
 	}
 
 	result := make(map[string]*ContractData)
+	check := m.schemaChecks.Begin(SchemaEndpointFunding)
 	
 	for _, item := range response.Data {
 		// MEXC的symbol格式如 BTC_USDT，转换为 BTCUSDT
@@ -240,14 +330,18 @@ func (m *MEXCExchange) FetchFundingRates() (map[string]*ContractData, error) {
 		}
 
 		ticker, ok := tickerMap[item.Symbol]
+
+		// 费率为数值字段，缺失时无法与0区分，用费率上限判断该条数据是否完整
+		check.Item()
+		check.Require("lastPrice", ok && ticker.Price > 0)
+		check.Require("fairPrice", ok && ticker.MarkPrice > 0)
+		check.Require("maxFundingRate", item.MaxFundingRate > 0)
+		check.Require("collectCycle", item.CollectCycle > 0)
+		check.Require("nextSettleTime", validNextFundingTime(item.NextSettleTime))
+
 		if !ok || ticker.Price <= 0 {
 			continue
 		}
-		
-		// 过滤24h交易额小于100万的合约
-		if ticker.Amount24 < 1000000 {
-			continue
-		}
 
 		intervalHour := float64(item.CollectCycle)
 		if intervalHour == 0 {
@@ -255,20 +349,290 @@ func (m *MEXCExchange) FetchFundingRates() (map[string]*ContractData, error) {
 		} else {
 			// 更新缓存
 			m.mu.Lock()
-			m.fundingIntervals[symbol] = intervalHour
+			updateFundingInterval(m.fundingIntervals, m.intervalChanges, symbol, intervalHour)
 			m.mu.Unlock()
 		}
 
 		// 转换为4小时费率
 		fundingRate4h := item.FundingRate * (4.0 / intervalHour)
 
-		result[symbol] = &ContractData{
+		contract := &ContractData{
 			Symbol:              symbol,
 			Price:               ticker.Price,
+			MarkPrice:           ticker.MarkPrice,
+			IndexPrice:          ticker.IndexPrice,
+			BidPrice:            ticker.BidPrice,
+			AskPrice:            ticker.AskPrice,
 			FundingRate:         item.FundingRate,
 			FundingIntervalHour: intervalHour,
 			FundingRate4h:       fundingRate4h,
 			NextFundingTime:     item.NextSettleTime,
+			FundingRateCap:      item.MaxFundingRate,
+			FundingRateFloor:    item.MinFundingRate,
+
+			FundingRatePredicted: m.FundingRatePredicted(),
+			QuoteVolume24h:       ticker.Amount24,
+		}
+
+		// 持仓价值 = 张数 × 每张币数量 × 标记价格
+		if ticker.HoldVol > 0 {
+			contract.OpenInterestUSDT = ticker.HoldVol * m.getContractSize(symbol) * contract.PriceFor(PriceSourceMark)
+		}
+
+		m.mu.RLock()
+		applyIntervalChange(contract, m.intervalChanges)
+		m.mu.RUnlock()
+
+		result[symbol] = contract
+	}
+
+	m.schemaChecks.Record(check)
+
+	return result, nil
+}
+
+func (m *MEXCExchange) FetchOrderBook(symbol string, limit int) (*OrderBook, error) {
+	url := fmt.Sprintf("https://contract.mexc.com/api/v1/contract/depth/%s?limit=%d", toUnderscoreSymbol(symbol), limit)
+
+	resp, err := m.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("请求订单簿失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取订单簿响应失败: %v", err)
+	}
+
+	var response struct {
+		Success bool `json:"success"`
+		Code    int  `json:"code"`
+		Data    struct {
+			Bids [][]interface{} `json:"bids"` // [价格, 张数, 订单数]
+			Asks [][]interface{} `json:"asks"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("解析订单簿响应失败: %v", err)
+	}
+
+	if !response.Success {
+		return nil, fmt.Errorf("API返回错误，code: %d", response.Code)
+	}
+
+	contractSize := m.getContractSize(symbol)
+
+	return &OrderBook{
+		Symbol: symbol,
+		Bids:   parseBookLevels(response.Data.Bids, contractSize),
+		Asks:   parseBookLevels(response.Data.Asks, contractSize),
+	}, nil
+}
+
+func (m *MEXCExchange) FetchServerTime() (int64, error) {
+	resp, err := m.client.Get("https://contract.mexc.com/api/v1/contract/ping")
+	if err != nil {
+		return 0, fmt.Errorf("请求服务器时间失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("读取服务器时间响应失败: %v", err)
+	}
+
+	var response struct {
+		Success bool  `json:"success"`
+		Code    int   `json:"code"`
+		Data    int64 `json:"data"` // 毫秒
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, fmt.Errorf("解析服务器时间响应失败: %v", err)
+	}
+
+	if !response.Success {
+		return 0, fmt.Errorf("API返回错误，code: %d", response.Code)
+	}
+
+	return response.Data, nil
+}
+
+func (m *MEXCExchange) FetchFundingHistory(symbol string, startTime, endTime int64) ([]FundingRecord, error) {
+	const pageSize = 100
+	var records []FundingRecord
+
+	// 接口不支持时间范围，按页倒序翻到早于startTime为止
+	for pageNum := 1; pageNum <= historyMaxPages; pageNum++ {
+		url := fmt.Sprintf("https://contract.mexc.com/api/v1/contract/funding_rate/history?symbol=%s&page_num=%d&page_size=%d",
+			toUnderscoreSymbol(symbol), pageNum, pageSize)
+
+		resp, err := m.client.Get(url)
+		if err != nil {
+			return nil, fmt.Errorf("请求历史资金费率失败: %v", err)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取历史资金费率响应失败: %v", err)
+		}
+
+		var response struct {
+			Success bool `json:"success"`
+			Code    int  `json:"code"`
+			Data    struct {
+				TotalPage  int `json:"totalPage"`
+				ResultList []struct {
+					FundingRate float64 `json:"fundingRate"`
+					SettleTime  int64   `json:"settleTime"`
+				} `json:"resultList"`
+			} `json:"data"`
+		}
+
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("解析历史资金费率响应失败: %v", err)
+		}
+
+		if !response.Success {
+			return nil, fmt.Errorf("API返回错误，code: %d", response.Code)
+		}
+
+		reachedStart := false
+		for _, item := range response.Data.ResultList {
+			if item.SettleTime < startTime {
+				reachedStart = true
+			}
+			records = append(records, FundingRecord{
+				Exchange:    m.Name(),
+				Symbol:      symbol,
+				FundingTime: item.SettleTime,
+				FundingRate: item.FundingRate,
+			})
+		}
+
+		if reachedStart || pageNum >= response.Data.TotalPage {
+			break
+		}
+		time.Sleep(historyPageDelay)
+	}
+
+	return filterFundingRecords(records, startTime, endTime), nil
+}
+
+func (m *MEXCExchange) FetchSpotTickers() (map[string]*SpotTicker, error) {
+	// 现货使用 api.mexc.com，与合约接口域名不同
+	resp, err := m.client.Get("https://api.mexc.com/api/v3/ticker/24hr")
+	if err != nil {
+		return nil, fmt.Errorf("请求现货行情失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取现货行情响应失败: %v", err)
+	}
+
+	var tickers []struct {
+		Symbol      string `json:"symbol"`
+		LastPrice   string `json:"lastPrice"`
+		BidPrice    string `json:"bidPrice"`
+		AskPrice    string `json:"askPrice"`
+		QuoteVolume string `json:"quoteVolume"`
+	}
+
+	if err := json.Unmarshal(body, &tickers); err != nil {
+		return nil, fmt.Errorf("解析现货行情响应失败: %v", err)
+	}
+
+	result := make(map[string]*SpotTicker)
+	for _, ticker := range tickers {
+		if !strings.HasSuffix(ticker.Symbol, "USDT") {
+			continue
+		}
+		price := parseFloat(ticker.LastPrice)
+		if price <= 0 {
+			continue
+		}
+		result[ticker.Symbol] = &SpotTicker{
+			Symbol:         ticker.Symbol,
+			Price:          price,
+			BidPrice:       parseFloat(ticker.BidPrice),
+			AskPrice:       parseFloat(ticker.AskPrice),
+			QuoteVolume24h: parseFloat(ticker.QuoteVolume),
+		}
+	}
+
+	return result, nil
+}
+
+// FetchFeeRate 查询账户的合约手续费率，按BTC_USDT的费率代表账户等级
+func (m *MEXCExchange) FetchFeeRate(client *AuthClient) (FeeRate, error) {
+	params := url.Values{}
+	params.Set("symbol", toUnderscoreSymbol(feeReferenceSymbol))
+
+	var response struct {
+		Success bool   `json:"success"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    struct {
+			MakerFee float64 `json:"makerFee"`
+			TakerFee float64 `json:"takerFee"`
+		} `json:"data"`
+	}
+
+	if err := client.Do(http.MethodGet, "/api/v1/private/account/tiered_fee_rate", params, nil, &response); err != nil {
+		return FeeRate{}, fmt.Errorf("请求手续费率失败: %v", err)
+	}
+
+	if !response.Success {
+		return FeeRate{}, fmt.Errorf("API返回错误: %d %s", response.Code, response.Message)
+	}
+
+	return FeeRate{
+		Maker: response.Data.MakerFee,
+		Taker: response.Data.TakerFee,
+	}, nil
+}
+
+// FetchAuthNetworkStatus 通过现货API的签名接口查询某币种各链的充提状态
+func (m *MEXCExchange) FetchAuthNetworkStatus(client *AuthClient, asset string) ([]NetworkStatus, error) {
+	var response []struct {
+		Coin        string `json:"coin"`
+		NetworkList []struct {
+			Network        string `json:"network"`
+			NetWork        string `json:"netWork"` // 链的简称，如 TRC20，network为显示名称
+			DepositEnable  bool   `json:"depositEnable"`
+			WithdrawEnable bool   `json:"withdrawEnable"`
+			WithdrawFee    string `json:"withdrawFee"`
+			MinConfirm     int    `json:"minConfirm"`
+		} `json:"networkList"`
+	}
+
+	if err := client.DoAt(mexcSpotBaseURL, http.MethodGet, "/api/v3/capital/config/getall", nil, nil, &response); err != nil {
+		return nil, fmt.Errorf("请求充提状态失败: %v", err)
+	}
+
+	var result []NetworkStatus
+	for _, coin := range response {
+		if coin.Coin != asset {
+			continue
+		}
+		for _, network := range coin.NetworkList {
+			name := network.NetWork
+			if name == "" {
+				name = network.Network
+			}
+			result = append(result, NetworkStatus{
+				Asset:           asset,
+				Network:         normalizeNetwork(name),
+				DepositEnabled:  network.DepositEnable,
+				WithdrawEnabled: network.WithdrawEnable,
+				WithdrawFee:     parseWithdrawFee(network.WithdrawFee),
+				Confirmations:   network.MinConfirm,
+			})
 		}
 	}
 
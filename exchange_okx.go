@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"net/url"
 	"strings"
 	"sync"
 	"time"
@@ -14,14 +16,22 @@ type OKXExchange struct {
 	client            *http.Client
 	fundingIntervals  map[string]float64 // symbol -> interval in hours
 	tradingSymbols    map[string]bool    // symbol -> is trading
+	contractSizes     map[string]float64 // symbol -> 每张合约面值（币）
+	intervalChanges   *IntervalTracker
+	statusTracker    *ContractStatusTracker
+	schemaChecks      *SchemaTracker
 	mu                sync.RWMutex
 }
 
-func NewOKXExchange() *OKXExchange {
+func NewOKXExchange(client *http.Client) *OKXExchange {
 	return &OKXExchange{
-		client:           &http.Client{Timeout: 10 * time.Second},
+		client:           client,
 		fundingIntervals: make(map[string]float64),
 		tradingSymbols:   make(map[string]bool),
+		contractSizes:    make(map[string]float64),
+		intervalChanges:  NewIntervalTracker("OKX"),
+		statusTracker:    NewContractStatusTracker("OKX"),
+		schemaChecks:     NewSchemaTracker("OKX"),
 	}
 }
 
@@ -29,6 +39,11 @@ func (o *OKXExchange) Name() string {
 	return "OKX"
 }
 
+// funding-rate的fundingRate为本期实时预估费率，settFundingRate为上一期已结算费率
+func (o *OKXExchange) FundingRatePredicted() bool {
+	return true
+}
+
 func (o *OKXExchange) Initialize() error {
 	return nil
 }
@@ -63,9 +78,16 @@ func (o *OKXExchange) UpdateFundingIntervals() error {
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
+	check := o.schemaChecks.Begin(SchemaEndpointInterval)
 	for _, item := range response.Data {
 		fundingTime := parseInt64(item.FundingTime)
 		nextFundingTime := parseInt64(item.NextFundingTime)
+
+		if strings.HasSuffix(item.InstID, "-USDT-SWAP") {
+			check.Item()
+			check.Require("fundingTime", validNextFundingTime(fundingTime))
+			check.Require("nextFundingTime", nextFundingTime > fundingTime)
+		}
 		
 		// 计算结算周期：下下次 - 下次
 		if fundingTime > 0 && nextFundingTime > fundingTime {
@@ -73,12 +95,13 @@ func (o *OKXExchange) UpdateFundingIntervals() error {
 			intervalHour := float64(intervalMs) / (1000.0 * 3600.0)
 			
 			// 转换为统一格式 (BTC-USDT-SWAP -> BTCUSDT)
-			if len(item.InstID) > 10 && item.InstID[len(item.InstID)-9:] == "-USDT-SWAP" {
+			if len(item.InstID) > 10 && strings.HasSuffix(item.InstID, "-USDT-SWAP") {
 				symbol := item.InstID[:len(item.InstID)-10] + "USDT"
-				o.fundingIntervals[symbol] = intervalHour
+				updateFundingInterval(o.fundingIntervals, o.intervalChanges, symbol, intervalHour)
 			}
 		}
 	}
+	o.schemaChecks.Record(check)
 
 	return nil
 }
@@ -101,6 +124,34 @@ func (o *OKXExchange) isTrading(symbol string) bool {
 	return ok && trading
 }
 
+// ContractEvents 取出合约上线、下架和状态变化事件
+func (o *OKXExchange) ContractEvents() []ContractEvent {
+	return o.statusTracker.Drain()
+}
+
+// IntervalChanges 取出尚未通知的结算周期变化
+func (o *OKXExchange) IntervalChanges() []IntervalChange {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.intervalChanges.Drain()
+}
+
+// SchemaChecks 取出每次获取的必需字段检查结果
+func (o *OKXExchange) SchemaChecks() []SchemaCheck {
+	return o.schemaChecks.Drain()
+}
+
+func (o *OKXExchange) getContractSize(symbol string) float64 {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if size, ok := o.contractSizes[symbol]; ok {
+		return size
+	}
+	return 1.0
+}
+
 func (o *OKXExchange) UpdateContractStatus() error {
 	url := "https://www.okx.com/api/v5/public/instruments?instType=SWAP"
 	
@@ -120,6 +171,7 @@ func (o *OKXExchange) UpdateContractStatus() error {
 		Data []struct {
 			InstID string `json:"instId"`
 			State  string `json:"state"`
+			CtVal  string `json:"ctVal"` // 合约面值
 		} `json:"data"`
 	}
 
@@ -127,9 +179,10 @@ func (o *OKXExchange) UpdateContractStatus() error {
 		return fmt.Errorf("解析响应失败: %v", err)
 	}
 
+	tradingSymbols := make(map[string]bool)
+	statuses := make(map[string]ContractStatus)
+
 	o.mu.Lock()
-	defer o.mu.Unlock()
-	
 	for _, item := range response.Data {
 		// 只处理USDT合约
 		if len(item.InstID) < 10 || !strings.HasSuffix(item.InstID, "-USDT-SWAP") {
@@ -138,8 +191,24 @@ func (o *OKXExchange) UpdateContractStatus() error {
 		
 		// 转换为统一格式 (BTC-USDT-SWAP -> BTCUSDT)
 		symbol := item.InstID[:len(item.InstID)-10] + "USDT"
-		o.tradingSymbols[symbol] = (item.State == "live")
+		tradingSymbols[symbol] = (item.State == "live")
+		if ctVal := parseFloat(item.CtVal); ctVal > 0 {
+			o.contractSizes[symbol] = ctVal
+		}
+
+		switch item.State {
+		case "live":
+			statuses[symbol] = ContractStatusTrading
+		case "preopen":
+			statuses[symbol] = ContractStatusPending
+		default:
+			statuses[symbol] = ContractStatusSuspended
+		}
 	}
+	o.tradingSymbols = tradingSymbols
+	o.mu.Unlock()
+
+	o.statusTracker.Update(statuses)
 
 	return nil
 }
@@ -165,6 +234,10 @@ func (o *OKXExchange) FetchFundingRates() (map[string]*ContractData, error) {
 			FundingRate     string `json:"fundingRate"`
 			FundingTime     string `json:"fundingTime"`     // 下次结算时间
 			NextFundingTime string `json:"nextFundingTime"` // 下下次结算时间
+			MaxFundingRate  string `json:"maxFundingRate"`  // 资金费率上限
+			MinFundingRate  string `json:"minFundingRate"`  // 资金费率下限
+			SettFundingRate string `json:"settFundingRate"` // 结算费率
+			SettState       string `json:"settState"`       // processing：结算中，settled：已结算
 		} `json:"data"`
 	}
 
@@ -193,8 +266,11 @@ func (o *OKXExchange) FetchFundingRates() (map[string]*ContractData, error) {
 		Code string `json:"code"`
 		Msg  string `json:"msg"`
 		Data []struct {
-			InstID string `json:"instId"`
-			Last   string `json:"last"`
+			InstID    string `json:"instId"`
+			Last      string `json:"last"`
+			BidPx     string `json:"bidPx"`
+			AskPx     string `json:"askPx"`
+			VolCcy24h string `json:"volCcy24h"` // 24h成交量（币）
 		} `json:"data"`
 	}
 
@@ -202,15 +278,43 @@ func (o *OKXExchange) FetchFundingRates() (map[string]*ContractData, error) {
 		return nil, fmt.Errorf("解析价格响应失败: %v", err)
 	}
 
-	priceMap := make(map[string]float64)
+	type TickerData struct {
+		Price       float64
+		BidPrice    float64
+		AskPrice    float64
+		QuoteVolume float64
+	}
+	tickerMap := make(map[string]TickerData)
 	for _, item := range priceResponse.Data {
 		if price := parseFloat(item.Last); price > 0 {
-			priceMap[item.InstID] = price
+			tickerMap[item.InstID] = TickerData{
+				Price:       price,
+				BidPrice:    parseFloat(item.BidPx),
+				AskPrice:    parseFloat(item.AskPx),
+				QuoteVolume: parseFloat(item.VolCcy24h) * price, // 换算为USDT成交额
+			}
 		}
 	}
 
+	// 标记价格、指数价格和持仓量只用于基差、价差和流动性过滤，获取失败时本轮不使用，不影响费率数据
+	markPriceMap, err := o.fetchMarkPrices()
+	if err != nil {
+		log.Printf("OKX 获取标记价格失败，本轮不使用标记价格: %v", err)
+	}
+
+	indexPriceMap, err := o.fetchIndexPrices()
+	if err != nil {
+		log.Printf("OKX 获取指数价格失败，本轮不使用指数价格: %v", err)
+	}
+
+	openInterestMap, err := o.fetchOpenInterest()
+	if err != nil {
+		log.Printf("OKX 获取持仓量失败，本轮不按持仓价值过滤: %v", err)
+	}
+
 	result := make(map[string]*ContractData)
-	
+	check := o.schemaChecks.Begin(SchemaEndpointFunding)
+
 	for _, item := range fundingResponse.Data {
 		// 只处理USDT合约
 		if len(item.InstID) < 10 || !strings.HasSuffix(item.InstID, "-USDT-SWAP") {
@@ -226,14 +330,26 @@ func (o *OKXExchange) FetchFundingRates() (map[string]*ContractData, error) {
 		}
 
 		fundingRate := parseFloat(item.FundingRate)
-		price, ok := priceMap[item.InstID]
-		if !ok || price <= 0 {
-			continue
-		}
-		
+		ticker, ok := tickerMap[item.InstID]
+
 		// 计算资金费率间隔：下下次 - 下次
 		fundingTime := parseInt64(item.FundingTime)
 		nextFundingTime := parseInt64(item.NextFundingTime)
+
+		check.Item()
+		check.Require("last", ok && ticker.Price > 0)
+		// 标记价格是辅助数据，获取失败时不检查，避免误报字段变化
+		if markPriceMap != nil {
+			check.Require("markPx", markPriceMap[item.InstID] > 0)
+		}
+		check.Require("fundingRate", item.FundingRate != "")
+		check.Require("fundingTime", validNextFundingTime(fundingTime))
+		check.Require("nextFundingTime", nextFundingTime > fundingTime)
+
+		if !ok || ticker.Price <= 0 {
+			continue
+		}
+
 		intervalHour := 8.0 // 默认
 		
 		if fundingTime > 0 && nextFundingTime > fundingTime {
@@ -242,7 +358,7 @@ func (o *OKXExchange) FetchFundingRates() (map[string]*ContractData, error) {
 			
 			// 更新缓存
 			o.mu.Lock()
-			o.fundingIntervals[symbol] = intervalHour
+			updateFundingInterval(o.fundingIntervals, o.intervalChanges, symbol, intervalHour)
 			o.mu.Unlock()
 		} else {
 			intervalHour = o.getFundingInterval(symbol)
@@ -251,14 +367,454 @@ func (o *OKXExchange) FetchFundingRates() (map[string]*ContractData, error) {
 		// 转换为4小时费率
 		fundingRate4h := fundingRate * (4.0 / intervalHour)
 
-		result[symbol] = &ContractData{
+		contract := &ContractData{
 			Symbol:              symbol,
-			Price:               price,
+			Price:               ticker.Price,
+			MarkPrice:           markPriceMap[item.InstID],
+			IndexPrice:          indexPriceMap[strings.TrimSuffix(item.InstID, "-SWAP")],
+			BidPrice:            ticker.BidPrice,
+			AskPrice:            ticker.AskPrice,
 			FundingRate:         fundingRate,
 			FundingIntervalHour: intervalHour,
 			FundingRate4h:       fundingRate4h,
 			NextFundingTime:     fundingTime, // 使用 fundingTime 作为下次结算时间
+			FundingRateCap:      parseFloat(item.MaxFundingRate),
+			FundingRateFloor:    parseFloat(item.MinFundingRate),
+
+			FundingRatePredicted: o.FundingRatePredicted(),
+			QuoteVolume24h:       ticker.QuoteVolume,
+			OpenInterestUSDT:     openInterestMap[item.InstID],
+		}
+
+		// settState为settled时，settFundingRate是上一期已结算的费率，结算时间为下次结算时间往前一个周期
+		if item.SettState == "settled" && item.SettFundingRate != "" && fundingTime > 0 {
+			contract.PrevFundingRate = parseFloat(item.SettFundingRate)
+			contract.PrevFundingTime = fundingTime - int64(intervalHour*3600*1000)
+		}
+
+		o.mu.RLock()
+		applyIntervalChange(contract, o.intervalChanges)
+		o.mu.RUnlock()
+
+		result[symbol] = contract
+	}
+
+	o.schemaChecks.Record(check)
+
+	return result, nil
+}
+
+// fetchMarkPrices 获取所有永续合约的标记价格，instId -> 标记价格
+func (o *OKXExchange) fetchMarkPrices() (map[string]float64, error) {
+	markURL := "https://www.okx.com/api/v5/public/mark-price?instType=SWAP"
+	markResp, err := o.client.Get(markURL)
+	if err != nil {
+		return nil, fmt.Errorf("请求标记价格失败: %v", err)
+	}
+	defer markResp.Body.Close()
+
+	markBody, err := io.ReadAll(markResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取标记价格响应失败: %v", err)
+	}
+
+	var markResponse struct {
+		Code string `json:"code"`
+		Data []struct {
+			InstID string `json:"instId"`
+			MarkPx string `json:"markPx"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(markBody, &markResponse); err != nil {
+		return nil, fmt.Errorf("解析标记价格响应失败: %v", err)
+	}
+
+	markPriceMap := make(map[string]float64)
+	for _, item := range markResponse.Data {
+		markPriceMap[item.InstID] = parseFloat(item.MarkPx)
+	}
+
+	return markPriceMap, nil
+}
+
+// fetchIndexPrices 获取USDT指数价格，指数的instId格式为 BTC-USDT
+func (o *OKXExchange) fetchIndexPrices() (map[string]float64, error) {
+	indexURL := "https://www.okx.com/api/v5/market/index-tickers?quoteCcy=USDT"
+	indexResp, err := o.client.Get(indexURL)
+	if err != nil {
+		return nil, fmt.Errorf("请求指数价格失败: %v", err)
+	}
+	defer indexResp.Body.Close()
+
+	indexBody, err := io.ReadAll(indexResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取指数价格响应失败: %v", err)
+	}
+
+	var indexResponse struct {
+		Code string `json:"code"`
+		Data []struct {
+			InstID string `json:"instId"`
+			IdxPx  string `json:"idxPx"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(indexBody, &indexResponse); err != nil {
+		return nil, fmt.Errorf("解析指数价格响应失败: %v", err)
+	}
+
+	indexPriceMap := make(map[string]float64)
+	for _, item := range indexResponse.Data {
+		indexPriceMap[item.InstID] = parseFloat(item.IdxPx)
+	}
+
+	return indexPriceMap, nil
+}
+
+// fetchOpenInterest 获取所有永续合约的持仓价值（USD），instId -> 持仓价值
+func (o *OKXExchange) fetchOpenInterest() (map[string]float64, error) {
+	oiURL := "https://www.okx.com/api/v5/public/open-interest?instType=SWAP"
+	oiResp, err := o.client.Get(oiURL)
+	if err != nil {
+		return nil, fmt.Errorf("请求持仓量失败: %v", err)
+	}
+	defer oiResp.Body.Close()
+
+	oiBody, err := io.ReadAll(oiResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取持仓量响应失败: %v", err)
+	}
+
+	var oiResponse struct {
+		Code string `json:"code"`
+		Data []struct {
+			InstID string `json:"instId"`
+			OiUsd  string `json:"oiUsd"` // 持仓价值（USD）
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(oiBody, &oiResponse); err != nil {
+		return nil, fmt.Errorf("解析持仓量响应失败: %v", err)
+	}
+
+	openInterestMap := make(map[string]float64)
+	for _, item := range oiResponse.Data {
+		openInterestMap[item.InstID] = parseFloat(item.OiUsd)
+	}
+
+	return openInterestMap, nil
+}
+
+func (o *OKXExchange) FetchOrderBook(symbol string, limit int) (*OrderBook, error) {
+	url := fmt.Sprintf("https://www.okx.com/api/v5/market/books?instId=%s&sz=%d", toOKXInstID(symbol), limit)
+
+	resp, err := o.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("请求订单簿失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取订单簿响应失败: %v", err)
+	}
+
+	var response struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+		Data []struct {
+			Bids [][]interface{} `json:"bids"`
+			Asks [][]interface{} `json:"asks"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("解析订单簿响应失败: %v", err)
+	}
+
+	if response.Code != "0" || len(response.Data) == 0 {
+		return nil, fmt.Errorf("API返回错误: %s %s", response.Code, response.Msg)
+	}
+
+	// OKX盘口数量单位为张，换算为币的数量
+	contractSize := o.getContractSize(symbol)
+
+	return &OrderBook{
+		Symbol: symbol,
+		Bids:   parseBookLevels(response.Data[0].Bids, contractSize),
+		Asks:   parseBookLevels(response.Data[0].Asks, contractSize),
+	}, nil
+}
+
+func (o *OKXExchange) FetchServerTime() (int64, error) {
+	resp, err := o.client.Get("https://www.okx.com/api/v5/public/time")
+	if err != nil {
+		return 0, fmt.Errorf("请求服务器时间失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("读取服务器时间响应失败: %v", err)
+	}
+
+	var response struct {
+		Code string `json:"code"`
+		Data []struct {
+			Ts string `json:"ts"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, fmt.Errorf("解析服务器时间响应失败: %v", err)
+	}
+
+	if response.Code != "0" || len(response.Data) == 0 {
+		return 0, fmt.Errorf("API返回错误: %s", response.Code)
+	}
+
+	return parseInt64(response.Data[0].Ts), nil
+}
+
+func (o *OKXExchange) FetchFundingHistory(symbol string, startTime, endTime int64) ([]FundingRecord, error) {
+	const limit = 100
+	var records []FundingRecord
+
+	// 结果按时间倒序，after为分页游标，返回早于该时间的记录
+	after := endTime + 1
+	for page := 0; page < historyMaxPages; page++ {
+		url := fmt.Sprintf("https://www.okx.com/api/v5/public/funding-rate-history?instId=%s&after=%d&limit=%d",
+			toOKXInstID(symbol), after, limit)
+
+		resp, err := o.client.Get(url)
+		if err != nil {
+			return nil, fmt.Errorf("请求历史资金费率失败: %v", err)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取历史资金费率响应失败: %v", err)
+		}
+
+		var response struct {
+			Code string `json:"code"`
+			Msg  string `json:"msg"`
+			Data []struct {
+				FundingRate  string `json:"fundingRate"`
+				RealizedRate string `json:"realizedRate"` // 实际结算费率
+				FundingTime  string `json:"fundingTime"`
+			} `json:"data"`
+		}
+
+		if err := json.Unmarshal(body, &response); err != nil {
+			return nil, fmt.Errorf("解析历史资金费率响应失败: %v", err)
+		}
+
+		if response.Code != "0" {
+			return nil, fmt.Errorf("API返回错误: %s %s", response.Code, response.Msg)
+		}
+
+		for _, item := range response.Data {
+			rate := item.RealizedRate
+			if rate == "" {
+				rate = item.FundingRate
+			}
+			records = append(records, FundingRecord{
+				Exchange:    o.Name(),
+				Symbol:      symbol,
+				FundingTime: parseInt64(item.FundingTime),
+				FundingRate: parseFloat(rate),
+			})
+		}
+
+		if len(response.Data) < limit {
+			break
+		}
+		after = parseInt64(response.Data[len(response.Data)-1].FundingTime)
+		if after <= startTime {
+			break
+		}
+		time.Sleep(historyPageDelay)
+	}
+
+	return filterFundingRecords(records, startTime, endTime), nil
+}
+
+func (o *OKXExchange) FetchSpotTickers() (map[string]*SpotTicker, error) {
+	resp, err := o.client.Get("https://www.okx.com/api/v5/market/tickers?instType=SPOT")
+	if err != nil {
+		return nil, fmt.Errorf("请求现货行情失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取现货行情响应失败: %v", err)
+	}
+
+	var response struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+		Data []struct {
+			InstID   string `json:"instId"`
+			Last     string `json:"last"`
+			BidPx    string `json:"bidPx"`
+			AskPx    string `json:"askPx"`
+			VolCcy24 string `json:"volCcy24h"` // 现货为计价货币成交额
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("解析现货行情响应失败: %v", err)
+	}
+
+	if response.Code != "0" {
+		return nil, fmt.Errorf("API返回错误: %s %s", response.Code, response.Msg)
+	}
+
+	result := make(map[string]*SpotTicker)
+	for _, item := range response.Data {
+		// BTC-USDT -> BTCUSDT
+		if !strings.HasSuffix(item.InstID, "-USDT") {
+			continue
+		}
+		price := parseFloat(item.Last)
+		if price <= 0 {
+			continue
+		}
+		symbol := strings.TrimSuffix(item.InstID, "-USDT") + "USDT"
+		result[symbol] = &SpotTicker{
+			Symbol:         symbol,
+			Price:          price,
+			BidPrice:       parseFloat(item.BidPx),
+			AskPrice:       parseFloat(item.AskPx),
+			QuoteVolume24h: parseFloat(item.VolCcy24),
+		}
+	}
+
+	return result, nil
+}
+
+func (o *OKXExchange) FetchBorrowRates() (map[string]*BorrowRate, error) {
+	resp, err := o.client.Get("https://www.okx.com/api/v5/public/interest-rate-loan-quota")
+	if err != nil {
+		return nil, fmt.Errorf("请求借币利率失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取借币利率响应失败: %v", err)
+	}
+
+	var response struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+		Data []struct {
+			Basic []struct {
+				Ccy   string `json:"ccy"`
+				Rate  string `json:"rate"`  // 日利率
+				Quota string `json:"quota"` // 基础借币限额
+			} `json:"basic"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("解析借币利率响应失败: %v", err)
+	}
+
+	if response.Code != "0" || len(response.Data) == 0 {
+		return nil, fmt.Errorf("API返回错误: %s %s", response.Code, response.Msg)
+	}
+
+	result := make(map[string]*BorrowRate)
+	for _, item := range response.Data[0].Basic {
+		result[item.Ccy] = &BorrowRate{
+			Asset:      item.Ccy,
+			HourlyRate: parseFloat(item.Rate) / 24,
+			Borrowable: parseFloat(item.Quota) > 0,
+		}
+	}
+
+	return result, nil
+}
+
+// FetchFeeRate 查询账户的永续合约手续费率
+// OKX以负数表示扣除的手续费、正数表示返佣，USDT本位合约使用makerU/takerU
+func (o *OKXExchange) FetchFeeRate(client *AuthClient) (FeeRate, error) {
+	params := url.Values{}
+	params.Set("instType", "SWAP")
+
+	var response struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+		Data []struct {
+			Level  string `json:"level"`
+			MakerU string `json:"makerU"`
+			TakerU string `json:"takerU"`
+		} `json:"data"`
+	}
+
+	if err := client.Do(http.MethodGet, "/api/v5/account/trade-fee", params, nil, &response); err != nil {
+		return FeeRate{}, fmt.Errorf("请求手续费率失败: %v", err)
+	}
+
+	if response.Code != "0" {
+		return FeeRate{}, fmt.Errorf("API返回错误: %s", response.Msg)
+	}
+
+	if len(response.Data) == 0 || response.Data[0].TakerU == "" {
+		return FeeRate{}, fmt.Errorf("响应中没有手续费率")
+	}
+
+	return FeeRate{
+		Maker: -parseFloat(response.Data[0].MakerU),
+		Taker: -parseFloat(response.Data[0].TakerU),
+	}, nil
+}
+
+// FetchAuthNetworkStatus 查询币种各链的充提状态，OKX的币种列表接口需要签名
+// 链名称带币种前缀，如 USDT-TRC20
+func (o *OKXExchange) FetchAuthNetworkStatus(client *AuthClient, asset string) ([]NetworkStatus, error) {
+	params := url.Values{}
+	params.Set("ccy", asset)
+
+	var response struct {
+		Code string `json:"code"`
+		Msg  string `json:"msg"`
+		Data []struct {
+			Ccy                  string `json:"ccy"`
+			Chain                string `json:"chain"`
+			CanDep               bool   `json:"canDep"`
+			CanWd                bool   `json:"canWd"`
+			MinFee               string `json:"minFee"` // 最小提币手续费
+			MinDepArrivalConfirm string `json:"minDepArrivalConfirm"`
+		} `json:"data"`
+	}
+
+	if err := client.Do(http.MethodGet, "/api/v5/asset/currencies", params, nil, &response); err != nil {
+		return nil, fmt.Errorf("请求充提状态失败: %v", err)
+	}
+
+	if response.Code != "0" {
+		return nil, fmt.Errorf("API返回错误: %s", response.Msg)
+	}
+
+	var result []NetworkStatus
+	for _, item := range response.Data {
+		if item.Ccy != asset {
+			continue
 		}
+		result = append(result, NetworkStatus{
+			Asset:           asset,
+			Network:         normalizeNetwork(strings.TrimPrefix(item.Chain, item.Ccy+"-")),
+			DepositEnabled:  item.CanDep,
+			WithdrawEnabled: item.CanWd,
+			WithdrawFee:     parseWithdrawFee(item.MinFee),
+			Confirmations:   int(parseInt64(item.MinDepArrivalConfirm)),
+		})
 	}
 
 	return result, nil
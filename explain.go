@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// projectionText 预测的每次结算费率（北京时间），如 "01-02 16:00 0.0100% → 01-03 00:00 0.0085%"
+func projectionText(settlements []projectedSettlement) string {
+	parts := make([]string, 0, len(settlements))
+	for _, settlement := range settlements {
+		parts = append(parts, fmt.Sprintf("%s %.4f%%",
+			time.UnixMilli(settlement.Time).In(time.FixedZone("CST", 8*3600)).Format("01-02 15:04"), settlement.Rate*100))
+	}
+	return strings.Join(parts, " → ")
+}
+
+// Explain 获取一次所有交易所的数据，说明某币种各交易所的费率预测和最好的时间窗口，不发送通知
+func (m *Monitor) Explain(symbol string) string {
+	return m.explainSymbol(symbol, m.fetchExchangeData())
+}
+
+// explainSymbol 输出某币种各交易所的预测结算路径，以及最好的时间窗口的收益明细（无论是否超过阈值）
+func (m *Monitor) explainSymbol(symbol string, exchangeData map[string]map[string]*ContractData) string {
+	now := m.clock.NowMs()
+	horizonEnd := now + int64(m.config.WindowHorizonHour*3600.0*1000.0)
+
+	message := fmt.Sprintf("📋 %s 费率预测与时间窗口\n", symbol)
+	message += fmt.Sprintf("当前时间: %s  观察期: %.0f小时  预测模型: %s\n\n",
+		time.UnixMilli(now).In(time.FixedZone("CST", 8*3600)).Format("01-02 15:04:05"), m.config.WindowHorizonHour, m.config.ProjectionModel)
+
+	var names []string
+	for name := range exchangeData {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	projection, hasHistory := m.projection.(historyProjection)
+
+	exchanges := make(map[string]*ContractData)
+	for _, name := range names {
+		contract, ok := exchangeData[name][symbol]
+		if hasHistory {
+			projection.PreloadHistory(name, symbol)
+		}
+		if !ok {
+			message += fmt.Sprintf("%s: 无数据（未上线、已下线或未通过流动性过滤）\n", name)
+			continue
+		}
+		exchanges[name] = contract
+
+		if !m.config.CanTrade(name) {
+			message += fmt.Sprintf("%s: 不在 TRADE_EXCHANGES 中，不参与配对\n", name)
+		}
+		message += fmt.Sprintf("%s: 费率 %.4f%%%s 周期 %gh 价格 %.4f 基差 %.4f%%%s\n",
+			name, contract.FundingRate*100, predictedTag(contract.FundingRatePredicted), contract.FundingIntervalHour,
+			contract.Price, contract.Basis()*100, fundingNote(contract.AtFundingCap(), 0, contract.FundingIntervalHour, 0))
+		if settlements := m.projectSettlements(name, contract, horizonEnd); len(settlements) > 0 {
+			message += fmt.Sprintf("  预测: %s\n", projectionText(settlements))
+		} else {
+			message += "  预测: 观察期内无结算\n"
+		}
+	}
+
+	// 所有交易所组合和时间窗口中最好的一个，以及超过阈值的各交易所组合
+	stats := &windowStats{}
+	pairs := m.analyzeWindows(symbol, exchanges, stats)
+	best := stats.best
+
+	if best == nil {
+		message += "\n少于两个可配对的交易所有有效数据，或观察期内没有可用的时间窗口\n"
+		return message
+	}
+
+	message += fmt.Sprintf("\n最好的时间窗口（共评估 %d 个交易所组合和时间窗口，按 %s 排序）:\n", stats.evaluated, m.config.RankBy)
+	message += explainOpportunity(best)
+
+	if len(pairs) == 0 {
+		message += "没有超过阈值的交易所组合，不会发送通知\n"
+		return message
+	}
+
+	m.sortOpportunities(pairs)
+	message += fmt.Sprintf("\n超过阈值的交易所组合（检查盘口深度后每个币种最多通知 %d 对）:\n", m.config.PairsPerSymbol)
+	for i, opp := range pairs {
+		message += fmt.Sprintf("%d. 做空 %s / 做多 %s", i+1, opp.HighRateExchange, opp.LowRateExchange)
+		if opp.HighRateExchange == best.HighRateExchange && opp.LowRateExchange == best.LowRateExchange &&
+			opp.EntryTimestamp == best.EntryTimestamp && opp.TargetTimestamp == best.TargetTimestamp {
+			message += " (即最好的时间窗口)\n"
+			continue
+		}
+		message += "\n" + explainOpportunity(&opp)
+	}
+
+	return message
+}
+
+// explainOpportunity 一个时间窗口的收益明细
+func explainOpportunity(opp *ArbitrageOpportunity) string {
+	message := ""
+	if opp.EntryTimestamp > 0 {
+		message += fmt.Sprintf("开仓时间: %s 结算后 (%.2f小时后)\n", opp.EntryTime.Format("01-02 15:04"), opp.TimeToEntry)
+	} else {
+		message += "开仓时间: 立即\n"
+	}
+	message += fmt.Sprintf("目标时间: %s 结算后平仓 (%.2f小时后, 持仓%.2f小时)\n",
+		opp.TargetTime.Format("01-02 15:04"), opp.TimeToTarget, opp.HoldingHours)
+
+	message += fmt.Sprintf("高费率: %s 累计 %.4f%% (%d次)\n", opp.HighRateExchange, opp.HighAccumulatedRate*100, opp.HighSettlements)
+	if len(opp.HighProjection) > 0 {
+		message += fmt.Sprintf("  %s\n", projectionText(opp.HighProjection))
+	}
+	message += fmt.Sprintf("低费率: %s 累计 %.4f%% (%d次)\n", opp.LowRateExchange, opp.LowAccumulatedRate*100, opp.LowSettlements)
+	if len(opp.LowProjection) > 0 {
+		message += fmt.Sprintf("  %s\n", projectionText(opp.LowProjection))
+	}
+
+	status := "未超过阈值"
+	if opp.NetProfit > opp.Threshold {
+		status = "超过阈值"
+	}
+	message += fmt.Sprintf("费差 %.4f%% - 价差 %.4f%% - 手续费 %.4f%% = 净收益 %.4f%% (阈值 %.2f%%, %s)\n",
+		opp.FundingEdge*100, opp.PriceSpread*100, opp.FeeCost*100, opp.NetProfit*100, opp.Threshold*100, status)
+	message += fmt.Sprintf("手续费(%s×2): %s %.4f%%%s / %s %.4f%%%s\n", feeOrderText(opp.FeeOrderType),
+		opp.HighRateExchange, opp.HighFeeRate*100, feeSourceTag(opp.HighFeeSource),
+		opp.LowRateExchange, opp.LowFeeRate*100, feeSourceTag(opp.LowFeeSource))
+	message += fmt.Sprintf("建议仓位: %.0f USDT/腿 (限制: %s)\n", opp.RecommendedNotional, opp.NotionalLimit)
+	message += fmt.Sprintf("预期收益: 资金费 %.2f - 价差 %.2f - 手续费 %.2f = %.2f USDT\n",
+		opp.FundingIncomeUSDT, opp.SpreadCostUSDT, opp.FeeUSDT, opp.NetProfitUSDT)
+	message += fmt.Sprintf("年化: %.1f%% 保证金收益率: %.2f%% (杠杆 %gx/%gx, 保证金 %.0f USDT)\n",
+		opp.APR*100, opp.ReturnOnMargin*100, opp.HighLeverage, opp.LowLeverage, opp.MarginUSDT)
+
+	return message
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// feeRefreshInterval 账户手续费率的缓存时间，交易所按日评定VIP等级
+// 查询失败时保留上一次的费率，随每小时的结算周期更新重试
+const feeRefreshInterval = 24 * time.Hour
+
+// feeReferenceSymbol 按交易对查询手续费率的交易所，使用该合约的费率代表账户等级
+const feeReferenceSymbol = "BTCUSDT"
+
+// 手续费率来源
+const (
+	FeeSourceAccount = "account" // 签名接口查询的账户费率
+	FeeSourceStatic  = "static"  // 配置的静态费率
+)
+
+// AccountFee 账户在某交易所适用的手续费率
+type AccountFee struct {
+	FeeRate
+	Source string // FeeSourceAccount / FeeSourceStatic
+}
+
+// feeCache 各账户查询到的手续费率
+type feeCache struct {
+	rates     map[string]FeeRate   // account_exchange -> 费率
+	fetchedAt map[string]time.Time // account_exchange -> 最近一次查询成功的时间
+	mu        sync.Mutex
+}
+
+// refreshFeeRates 查询已配置凭证的账户手续费率，缓存未过期的跳过
+func (m *Monitor) refreshFeeRates() {
+	m.fees.mu.Lock()
+	defer m.fees.mu.Unlock()
+
+	if m.fees.rates == nil {
+		m.fees.rates = make(map[string]FeeRate)
+		m.fees.fetchedAt = make(map[string]time.Time)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, exchange := range m.exchanges {
+		market, ok := exchange.(FeeMarket)
+		if !ok {
+			continue
+		}
+
+		for account := range m.accounts {
+			client := m.accounts.Get(account, exchange.Name())
+			if client == nil {
+				continue
+			}
+
+			key := account + "_" + exchange.Name()
+			if fetchedAt, ok := m.fees.fetchedAt[key]; ok && localNow().Sub(fetchedAt) < feeRefreshInterval {
+				continue
+			}
+
+			wg.Add(1)
+			go func(name, account, key string, market FeeMarket, client *AuthClient) {
+				defer wg.Done()
+				fee, err := market.FetchFeeRate(client)
+				if err != nil {
+					log.Printf("%s 账户 %s 查询手续费率失败: %v", name, account, err)
+					return
+				}
+
+				mu.Lock()
+				defer mu.Unlock()
+				if old, ok := m.fees.rates[key]; !ok || old != fee {
+					log.Printf("%s 账户 %s 手续费率: 挂单 %.4f%% 吃单 %.4f%%", name, account, fee.Maker*100, fee.Taker*100)
+				}
+				m.fees.rates[key] = fee
+				m.fees.fetchedAt[key] = localNow()
+			}(exchange.Name(), account, key, market, client)
+		}
+	}
+
+	wg.Wait()
+}
+
+// FeeRateFor 账户在交易所的手续费率，未配置凭证或尚未查询成功时使用静态费率
+func (m *Monitor) FeeRateFor(account, exchange string) AccountFee {
+	m.fees.mu.Lock()
+	fee, ok := m.fees.rates[account+"_"+exchange]
+	m.fees.mu.Unlock()
+
+	if ok {
+		return AccountFee{FeeRate: fee, Source: FeeSourceAccount}
+	}
+	return AccountFee{FeeRate: m.config.FeeRates[exchange], Source: FeeSourceStatic}
+}
+
+// tradingFee 每次开仓或平仓成交的手续费率，按 FEE_ORDER_TYPE 取吃单或挂单费率，同时返回费率来源
+func (m *Monitor) tradingFee(exchange string) (float64, string) {
+	fee := m.FeeRateFor(DefaultAccount, exchange)
+	if m.config.FeeOrderType == FeeOrderMaker {
+		return fee.Maker, fee.Source
+	}
+	return fee.Taker, fee.Source
+}
+
+// spotTradingFee 现货每次买入或卖出成交的手续费率，按 FEE_ORDER_TYPE 取吃单或挂单费率
+func (m *Monitor) spotTradingFee(exchange string) float64 {
+	fee := m.config.SpotFeeRates[exchange]
+	if m.config.FeeOrderType == FeeOrderMaker {
+		return fee.Maker
+	}
+	return fee.Taker
+}
+
+// feeOrderText 成交方式的中文描述
+func feeOrderText(orderType string) string {
+	if orderType == FeeOrderMaker {
+		return "挂单"
+	}
+	return "吃单"
+}
+
+// feeSourceTag 通知中的手续费率来源标注
+func feeSourceTag(source string) string {
+	if source == FeeSourceAccount {
+		return "(账户)"
+	}
+	return "(默认)"
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// FundingRateLimit 资金费率上下限，0表示交易所未提供
+type FundingRateLimit struct {
+	Cap   float64 // 上限（正数）
+	Floor float64 // 下限（负数）
+}
+
+// IntervalChange 结算周期变化记录
+type IntervalChange struct {
+	Exchange        string
+	Symbol          string
+	OldIntervalHour float64
+	NewIntervalHour float64
+	ChangedAt       time.Time
+}
+
+// IntervalTracker 记录各合约最近一次结算周期变化，以及尚未通知的变化
+// 不带锁，调用方需持有对应交易所的锁
+type IntervalTracker struct {
+	exchange string
+	latest   map[string]IntervalChange // symbol -> 最近一次变化
+	pending  []IntervalChange
+}
+
+func NewIntervalTracker(exchange string) *IntervalTracker {
+	return &IntervalTracker{
+		exchange: exchange,
+		latest:   make(map[string]IntervalChange),
+	}
+}
+
+// Drain 取出并清空尚未通知的变化
+func (t *IntervalTracker) Drain() []IntervalChange {
+	changes := t.pending
+	t.pending = nil
+	return changes
+}
+
+// updateFundingInterval 写入结算周期缓存，与已缓存的值不同时记录变化
+// 首次获取到的周期只作为基准。调用方需持有对应交易所的写锁
+func updateFundingInterval(intervals map[string]float64, changes *IntervalTracker, symbol string, intervalHour float64) {
+	if old, ok := intervals[symbol]; ok && old != intervalHour {
+		change := IntervalChange{
+			Exchange:        changes.exchange,
+			Symbol:          symbol,
+			OldIntervalHour: old,
+			NewIntervalHour: intervalHour,
+			ChangedAt:       localNow(),
+		}
+		changes.latest[symbol] = change
+		changes.pending = append(changes.pending, change)
+	}
+	intervals[symbol] = intervalHour
+}
+
+// applyIntervalChange 将最近一次结算周期变化写入合约数据
+func applyIntervalChange(contract *ContractData, changes *IntervalTracker) {
+	if change, ok := changes.latest[contract.Symbol]; ok {
+		contract.PrevFundingIntervalHour = change.OldIntervalHour
+		contract.IntervalChangedAt = change.ChangedAt.UnixMilli()
+	}
+}
+
+// applyFundingLimit 将费率上下限写入合约数据
+func applyFundingLimit(contract *ContractData, limits map[string]FundingRateLimit) {
+	if limit, ok := limits[contract.Symbol]; ok {
+		contract.FundingRateCap = limit.Cap
+		contract.FundingRateFloor = limit.Floor
+	}
+}
+
+// rollForwardFundingTime 下次结算时间已过期时按结算周期向后推算到now之后的第一次结算
+// 返回推算后的时间，以及是否进行了推算
+func rollForwardFundingTime(nextFundingTime int64, intervalHour float64, now int64) (int64, bool) {
+	intervalMs := int64(intervalHour * 3600.0 * 1000.0)
+	if nextFundingTime <= 0 || nextFundingTime > now || intervalMs <= 0 {
+		return nextFundingTime, false
+	}
+
+	periods := (now-nextFundingTime)/intervalMs + 1
+	return nextFundingTime + periods*intervalMs, true
+}
+
+// recentPrevInterval 结算周期在标注窗口内发生过变化时返回变化前的周期，否则返回0
+func (m *Monitor) recentPrevInterval(contract *ContractData) float64 {
+	if contract.PrevFundingIntervalHour <= 0 || contract.IntervalChangedAt <= 0 {
+		return 0
+	}
+
+	window := time.Duration(m.config.IntervalChangeWindowHour * float64(time.Hour))
+	if localNow().Sub(time.UnixMilli(contract.IntervalChangedAt)) > window {
+		return 0
+	}
+	return contract.PrevFundingIntervalHour
+}
+
+// handleIntervalChanges 收集各交易所的结算周期变化并发送通知
+func (m *Monitor) handleIntervalChanges() {
+	var changes []IntervalChange
+	for _, exchange := range m.exchanges {
+		changes = append(changes, exchange.IntervalChanges()...)
+	}
+
+	if len(changes) == 0 {
+		return
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Symbol != changes[j].Symbol {
+			return changes[i].Symbol < changes[j].Symbol
+		}
+		return changes[i].Exchange < changes[j].Exchange
+	})
+
+	message := fmt.Sprintf("⏱ 结算周期变化 (%d)\n\n", len(changes))
+	for _, change := range changes {
+		line := fmt.Sprintf("%s %s %.0fh→%.0fh", change.Exchange, change.Symbol, change.OldIntervalHour, change.NewIntervalHour)
+		log.Printf("结算周期变化: %s", line)
+		message += line + "\n"
+	}
+
+	if m.notify == nil {
+		return
+	}
+
+	if err := m.notify(message); err != nil {
+		log.Printf("发送结算周期变化通知失败: %v", err)
+	}
+}
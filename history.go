@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// historyPageDelay 分页请求历史资金费率的间隔，避免触发交易所限频
+const historyPageDelay = 200 * time.Millisecond
+
+// historyMaxPages 单次获取历史资金费率的最大页数
+const historyMaxPages = 500
+
+// HistoryStore 本地历史资金费率存储，每个交易所+币种一个JSON Lines文件
+type HistoryStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func NewHistoryStore(dir string) *HistoryStore {
+	return &HistoryStore{dir: dir}
+}
+
+func (s *HistoryStore) path(exchange, symbol string) string {
+	return filepath.Join(s.dir, exchange, symbol+".jsonl")
+}
+
+// Load 读取某交易所某币种的全部历史记录，按结算时间升序
+func (s *HistoryStore) Load(exchange, symbol string) ([]FundingRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.load(exchange, symbol)
+}
+
+func (s *HistoryStore) load(exchange, symbol string) ([]FundingRecord, error) {
+	file, err := os.Open(s.path(exchange, symbol))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("打开历史文件失败: %v", err)
+	}
+	defer file.Close()
+
+	var records []FundingRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record FundingRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取历史文件失败: %v", err)
+	}
+
+	return records, nil
+}
+
+// Save 合并写入历史记录，按结算时间去重，返回新增的条数
+func (s *HistoryStore) Save(exchange, symbol string, records []FundingRecord) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.load(exchange, symbol)
+	if err != nil {
+		return 0, err
+	}
+
+	merged := make(map[int64]FundingRecord, len(existing)+len(records))
+	for _, record := range existing {
+		merged[record.FundingTime] = record
+	}
+	added := 0
+	for _, record := range records {
+		if _, ok := merged[record.FundingTime]; !ok {
+			added++
+		}
+		merged[record.FundingTime] = record
+	}
+
+	all := make([]FundingRecord, 0, len(merged))
+	for _, record := range merged {
+		all = append(all, record)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].FundingTime < all[j].FundingTime
+	})
+
+	path := s.path(exchange, symbol)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, fmt.Errorf("创建历史目录失败: %v", err)
+	}
+
+	// 先写临时文件再替换，避免写入中断导致文件损坏
+	tmpPath := path + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return 0, fmt.Errorf("创建历史文件失败: %v", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, record := range all {
+		if err := encoder.Encode(record); err != nil {
+			file.Close()
+			return 0, fmt.Errorf("写入历史文件失败: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return 0, fmt.Errorf("写入历史文件失败: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return 0, fmt.Errorf("写入历史文件失败: %v", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return 0, fmt.Errorf("替换历史文件失败: %v", err)
+	}
+
+	return added, nil
+}
+
+// filterFundingRecords 只保留 [startTime, endTime] 内的记录
+func filterFundingRecords(records []FundingRecord, startTime, endTime int64) []FundingRecord {
+	var result []FundingRecord
+	for _, record := range records {
+		if record.FundingTime >= startTime && record.FundingTime <= endTime {
+			result = append(result, record)
+		}
+	}
+	return result
+}
+
+// RunBackfill 从所有交易所回补指定币种和时间范围的历史资金费率到本地存储
+func RunBackfill(exchanges []Exchange, store *HistoryStore, symbols []string, start, end time.Time) {
+	startTime := start.UnixMilli()
+	endTime := end.UnixMilli()
+
+	log.Printf("开始回补历史资金费率: %d 个币种, %s ~ %s",
+		len(symbols), start.Format("2006-01-02 15:04"), end.Format("2006-01-02 15:04"))
+
+	var wg sync.WaitGroup
+	for _, exchange := range exchanges {
+		wg.Add(1)
+		// 每个交易所一个协程，交易所内按币种顺序请求，避免触发限频
+		go func(ex Exchange) {
+			defer wg.Done()
+			for _, symbol := range symbols {
+				records, err := ex.FetchFundingHistory(symbol, startTime, endTime)
+				if err != nil {
+					log.Printf("%s %s 获取历史资金费率失败: %v", ex.Name(), symbol, err)
+					continue
+				}
+
+				added, err := store.Save(ex.Name(), symbol, records)
+				if err != nil {
+					log.Printf("%s %s 保存历史资金费率失败: %v", ex.Name(), symbol, err)
+					continue
+				}
+
+				log.Printf("%s %s 获取 %d 条，新增 %d 条", ex.Name(), symbol, len(records), added)
+			}
+		}(exchange)
+	}
+	wg.Wait()
+
+	log.Println("历史资金费率回补完成")
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// httpTimeout 交易所REST请求超时时间
+const httpTimeout = 10 * time.Second
+
+// ProxyError 连接代理失败（代理不可达、认证失败或代理拒绝转发），与交易所本身的错误区分
+type ProxyError struct {
+	Proxy string // 去掉认证信息的代理地址
+	Err   error
+}
+
+func (e *ProxyError) Error() string {
+	return fmt.Sprintf("代理 %s 连接失败: %v", e.Proxy, e.Err)
+}
+
+func (e *ProxyError) Unwrap() error {
+	return e.Err
+}
+
+// isProxyError 错误是否来自代理连接
+func isProxyError(err error) bool {
+	var proxyErr *ProxyError
+	if errors.As(err, &proxyErr) {
+		return true
+	}
+
+	// 代理返回非200的CONNECT响应、SOCKS5握手失败等，由net/http包装后只能按错误信息识别
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "proxyconnect" {
+		return true
+	}
+	return strings.Contains(err.Error(), "socks connect")
+}
+
+// parseProxyURL 解析代理地址，支持 http / https / socks5，空字符串表示直连
+func parseProxyURL(proxyURL string) (*url.URL, error) {
+	if proxyURL == "" {
+		return nil, nil
+	}
+
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("代理地址格式错误: %v", err)
+	}
+
+	switch parsed.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("不支持的代理协议: %s", parsed.Scheme)
+	}
+
+	if parsed.Host == "" {
+		return nil, fmt.Errorf("代理地址缺少主机: %s", proxyURL)
+	}
+
+	return parsed, nil
+}
+
+// newHTTPClient 创建交易所使用的HTTP客户端，proxyURL为空时直连
+// 请求结果记录到health，代理连接失败单独计数；recorder不为nil时录制所有请求和响应
+func newHTTPClient(exchange, proxyURL string, health *HealthTracker, recorder *Recorder) (*http.Client, error) {
+	proxy, err := parseProxyURL(proxyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	proxyName := ""
+
+	if proxy != nil {
+		proxyName = proxy.Redacted()
+		transport.Proxy = http.ProxyURL(proxy)
+
+		// 配置代理后所有连接都发往代理，拨号失败即为代理不可达
+		dialer := &net.Dialer{Timeout: httpTimeout, KeepAlive: 30 * time.Second}
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			conn, err := dialer.DialContext(ctx, network, addr)
+			if err != nil {
+				return nil, &ProxyError{Proxy: proxyName, Err: err}
+			}
+			return conn, nil
+		}
+	}
+
+	var base http.RoundTripper = transport
+	if recorder != nil {
+		if base, err = recorder.Transport(exchange, transport); err != nil {
+			return nil, err
+		}
+	}
+
+	return newTrackedClient(exchange, proxyName, base, health), nil
+}
+
+// newTrackedClient 创建记录健康状态的HTTP客户端
+func newTrackedClient(exchange, proxyName string, base http.RoundTripper, health *HealthTracker) *http.Client {
+	health.register(exchange, proxyName)
+
+	return &http.Client{
+		Timeout: httpTimeout,
+		Transport: &healthTransport{
+			exchange: exchange,
+			proxy:    proxyName,
+			base:     base,
+			health:   health,
+		},
+	}
+}
+
+// healthTransport 记录每次请求的结果，并在代理错误的信息中注明是代理问题
+type healthTransport struct {
+	exchange string
+	proxy    string
+	base     http.RoundTripper
+	health   *HealthTracker
+}
+
+func (t *healthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		if t.proxy != "" && isProxyError(err) {
+			t.health.recordProxyFailure(t.exchange, err)
+			var proxyErr *ProxyError
+			if !errors.As(err, &proxyErr) {
+				err = &ProxyError{Proxy: t.proxy, Err: err}
+			}
+			return nil, err
+		}
+		t.health.recordExchangeFailure(t.exchange, err)
+		return nil, err
+	}
+
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		t.health.recordExchangeFailure(t.exchange, fmt.Errorf("HTTP %d", resp.StatusCode))
+	} else {
+		t.health.recordSuccess(t.exchange)
+	}
+
+	return resp, nil
+}
+
+// ExchangeHealth 交易所连接健康状态
+type ExchangeHealth struct {
+	Exchange         string
+	Proxy            string // 使用的代理，空表示直连
+	LastSuccess      time.Time
+	LastError        string
+	LastErrorAt      time.Time
+	LastErrorIsProxy bool
+	ProxyFailures    int64 // 代理连接失败次数
+	ExchangeFailures int64 // 交易所请求失败次数（超时、5xx、限频等）
+}
+
+// Status 健康状态描述：最近一次请求成功为正常，否则按最近一次错误区分代理异常和交易所异常
+func (h ExchangeHealth) Status() string {
+	switch {
+	case h.LastErrorAt.IsZero() || h.LastSuccess.After(h.LastErrorAt):
+		return "正常"
+	case h.LastErrorIsProxy:
+		return "代理异常"
+	default:
+		return "交易所异常"
+	}
+}
+
+// HealthTracker 各交易所连接的健康状态
+type HealthTracker struct {
+	states map[string]*ExchangeHealth
+	mu     sync.Mutex
+}
+
+func NewHealthTracker() *HealthTracker {
+	return &HealthTracker{
+		states: make(map[string]*ExchangeHealth),
+	}
+}
+
+func (h *HealthTracker) register(exchange, proxy string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.states[exchange] = &ExchangeHealth{Exchange: exchange, Proxy: proxy}
+}
+
+func (h *HealthTracker) recordSuccess(exchange string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if state, ok := h.states[exchange]; ok {
+		state.LastSuccess = time.Now()
+	}
+}
+
+func (h *HealthTracker) recordProxyFailure(exchange string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if state, ok := h.states[exchange]; ok {
+		state.ProxyFailures++
+		state.LastError = err.Error()
+		state.LastErrorAt = time.Now()
+		state.LastErrorIsProxy = true
+	}
+}
+
+func (h *HealthTracker) recordExchangeFailure(exchange string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if state, ok := h.states[exchange]; ok {
+		state.ExchangeFailures++
+		state.LastError = err.Error()
+		state.LastErrorAt = time.Now()
+		state.LastErrorIsProxy = false
+	}
+}
+
+// Snapshot 按交易所名称排序的健康状态副本
+func (h *HealthTracker) Snapshot() []ExchangeHealth {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	result := make([]ExchangeHealth, 0, len(h.states))
+	for _, state := range h.states {
+		result = append(result, *state)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Exchange < result[j].Exchange
+	})
+	return result
+}
+
+// LogSummary 输出各交易所的健康状态
+func (h *HealthTracker) LogSummary() {
+	for _, state := range h.Snapshot() {
+		proxy := "直连"
+		if state.Proxy != "" {
+			proxy = state.Proxy
+		}
+		if state.Status() == "正常" {
+			log.Printf("%s 连接状态: 正常 (%s)", state.Exchange, proxy)
+			continue
+		}
+		log.Printf("%s 连接状态: %s (%s)，代理失败 %d 次，交易所失败 %d 次，最近错误: %s",
+			state.Exchange, state.Status(), proxy, state.ProxyFailures, state.ExchangeFailures, state.LastError)
+	}
+}
+
+// exchangeConstructors 所有交易所的构造函数
+var exchangeConstructors = []struct {
+	name string
+	new  func(client *http.Client) Exchange
+}{
+	{"Binance", func(client *http.Client) Exchange { return NewBinanceExchange(client) }},
+	{"OKX", func(client *http.Client) Exchange { return NewOKXExchange(client) }},
+	{"Bybit", func(client *http.Client) Exchange { return NewBybitExchange(client) }},
+	{"MEXC", func(client *http.Client) Exchange { return NewMEXCExchange(client) }},
+	{"Bitget", func(client *http.Client) Exchange { return NewBitgetExchange(client) }},
+	{"Gate", func(client *http.Client) Exchange { return NewGateExchange(client) }},
+}
+
+// newExchanges 按配置创建所有交易所，各交易所可使用独立的代理；配置了RECORD_DIR时录制所有请求
+func newExchanges(config *Config, health *HealthTracker) ([]Exchange, error) {
+	var recorder *Recorder
+	if config.RecordDir != "" {
+		var err error
+		if recorder, err = NewRecorder(config.RecordDir); err != nil {
+			return nil, err
+		}
+		log.Printf("录制交易所请求到: %s", recorder.Dir())
+	}
+
+	exchanges := make([]Exchange, 0, len(exchangeConstructors))
+	for _, c := range exchangeConstructors {
+		proxyURL := config.ProxyFor(c.name)
+		client, err := newHTTPClient(c.name, proxyURL, health, recorder)
+		if err != nil {
+			return nil, fmt.Errorf("%s 创建HTTP客户端失败: %v", c.name, err)
+		}
+		if proxyURL != "" {
+			log.Printf("%s 使用代理: %s", c.name, redactProxy(proxyURL))
+		}
+		exchanges = append(exchanges, c.new(client))
+	}
+
+	return exchanges, nil
+}
+
+// newReplayExchanges 创建从录制数据读取响应的交易所，不访问网络
+func newReplayExchanges(replay *Replay, health *HealthTracker) []Exchange {
+	exchanges := make([]Exchange, 0, len(exchangeConstructors))
+	for _, c := range exchangeConstructors {
+		client := newTrackedClient(c.name, "", replay.Transport(c.name), health)
+		exchanges = append(exchanges, c.new(client))
+	}
+	return exchanges
+}
+
+// redactProxy 去掉代理地址中的密码，用于日志
+func redactProxy(proxyURL string) string {
+	if parsed, err := url.Parse(proxyURL); err == nil {
+		return parsed.Redacted()
+	}
+	return proxyURL
+}
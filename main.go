@@ -2,8 +2,10 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -17,6 +19,19 @@ func main() {
 
 	// 添加测试标志
 	testFlag := flag.Bool("test", false, "运行测试模式")
+	testAuthFlag := flag.Bool("test-auth", false, "在本地模拟交易所验签，测试各交易所的签名请求")
+
+	// 历史资金费率回补
+	backfillFlag := flag.Bool("backfill", false, "回补历史资金费率到本地存储")
+	symbolsFlag := flag.String("symbols", "", "回补的币种，逗号分隔，如 BTCUSDT,ETHUSDT")
+	startFlag := flag.String("start", "", "回补起始日期 YYYY-MM-DD，默认结束日期前30天")
+	endFlag := flag.String("end", "", "回补结束日期 YYYY-MM-DD（含当天），默认今天")
+
+	// 回放录制的交易所响应
+	replayFlag := flag.String("replay", "", "回放录制目录中的交易所响应，如 data/recordings/20240101-120000")
+
+	// 说明某币种的费率预测和时间窗口
+	explainFlag := flag.String("explain", "", "获取一次数据，输出该币种各交易所的预测结算路径和最好的时间窗口，如 BTCUSDT")
 	flag.Parse()
 
 	if *testFlag {
@@ -24,6 +39,26 @@ func main() {
 		return
 	}
 
+	if *testAuthFlag {
+		TestAuthSigning()
+		return
+	}
+
+	if *backfillFlag {
+		runBackfillCommand(*symbolsFlag, *startFlag, *endFlag)
+		return
+	}
+
+	if *replayFlag != "" {
+		runReplayCommand(*replayFlag)
+		return
+	}
+
+	if *explainFlag != "" {
+		runExplainCommand(strings.ToUpper(strings.TrimSpace(*explainFlag)))
+		return
+	}
+
 	// 从环境变量获取微信webhook
 	webhookURL := os.Getenv("WECHAT_WEBHOOK")
 	if webhookURL == "" {
@@ -32,7 +67,32 @@ func main() {
 		log.Printf("已加载微信webhook配置")
 	}
 
-	monitor := NewMonitor(webhookURL, 0.02) // 阈值由周期动态决定
+	config := LoadConfig()
+	log.Printf("价差计算使用价格: %s", config.PriceSource)
+	if len(config.TradeExchanges) > 0 {
+		var names []string
+		for _, name := range exchangeNames {
+			if config.TradeExchanges[name] {
+				names = append(names, name)
+			}
+		}
+		log.Printf("只在以下交易所开仓: %s", strings.Join(names, ", "))
+	}
+
+	health := NewHealthTracker()
+	exchanges, err := newExchanges(config, health)
+	if err != nil {
+		log.Fatalf("创建交易所失败: %v", err)
+	}
+
+	monitor := NewMonitor(webhookURL, 0.02, config, exchanges, health) // 阈值由周期动态决定
+
+	accounts, err := newAccountClients(config, health)
+	if err != nil {
+		log.Fatalf("创建签名客户端失败: %v", err)
+	}
+	logAccounts(accounts)
+	monitor.SetAccounts(accounts)
 
 	// 首次获取所有交易所的资金费率结算周期信息
 	log.Println("正在初始化，获取所有交易所的资金费率结算周期...")
@@ -40,6 +100,9 @@ func main() {
 		log.Fatalf("初始化失败: %v", err)
 	}
 
+	// 同步交易所服务器时间
+	monitor.SyncClocks()
+
 	log.Println("初始化完成，开始监控...")
 	
 	// 每10秒获取一次数据并分析
@@ -50,6 +113,10 @@ func main() {
 	intervalTicker := time.NewTicker(1 * time.Hour)
 	defer intervalTicker.Stop()
 
+	// 定期同步交易所服务器时间
+	clockTicker := time.NewTicker(time.Duration(config.ClockSyncIntervalMin) * time.Minute)
+	defer clockTicker.Stop()
+
 	// 立即执行一次
 	monitor.CheckArbitrageOpportunities()
 
@@ -60,6 +127,132 @@ func main() {
 		case <-intervalTicker.C:
 			log.Println("更新资金费率结算周期和合约状态...")
 			monitor.UpdateFundingIntervals()
+		case <-clockTicker.C:
+			monitor.SyncClocks()
+		}
+	}
+}
+
+// runExplainCommand 按实时监控的流程初始化后获取一次数据，输出某币种的费率预测和时间窗口，不发送通知
+func runExplainCommand(symbol string) {
+	config := LoadConfig()
+	health := NewHealthTracker()
+	exchanges, err := newExchanges(config, health)
+	if err != nil {
+		log.Fatalf("创建交易所失败: %v", err)
+	}
+
+	monitor := NewMonitor("", 0.02, config, exchanges, health)
+
+	accounts, err := newAccountClients(config, health)
+	if err != nil {
+		log.Fatalf("创建签名客户端失败: %v", err)
+	}
+	monitor.SetAccounts(accounts)
+
+	if err := monitor.InitializeExchanges(); err != nil {
+		log.Fatalf("初始化失败: %v", err)
+	}
+	monitor.SyncClocks()
+
+	fmt.Print(monitor.Explain(symbol))
+}
+
+func runBackfillCommand(symbolsArg, startArg, endArg string) {
+	var symbols []string
+	for _, symbol := range strings.Split(symbolsArg, ",") {
+		if symbol = strings.ToUpper(strings.TrimSpace(symbol)); symbol != "" {
+			symbols = append(symbols, symbol)
+		}
+	}
+	if len(symbols) == 0 {
+		log.Fatalf("请通过 -symbols 指定回补的币种")
+	}
+
+	// 结束日期包含当天
+	end := time.Now()
+	if endArg != "" {
+		day, err := time.ParseInLocation("2006-01-02", endArg, time.Local)
+		if err != nil {
+			log.Fatalf("结束日期格式错误: %v", err)
+		}
+		end = day.Add(24*time.Hour - time.Millisecond)
+	}
+
+	start := end.AddDate(0, 0, -30)
+	if startArg != "" {
+		day, err := time.ParseInLocation("2006-01-02", startArg, time.Local)
+		if err != nil {
+			log.Fatalf("起始日期格式错误: %v", err)
+		}
+		start = day
+	}
+
+	if !start.Before(end) {
+		log.Fatalf("起始日期必须早于结束日期")
+	}
+
+	config := LoadConfig()
+	exchanges, err := newExchanges(config, NewHealthTracker())
+	if err != nil {
+		log.Fatalf("创建交易所失败: %v", err)
+	}
+
+	RunBackfill(exchanges, NewHistoryStore(config.HistoryDir), symbols, start, end)
+}
+
+// runReplayCommand 按实时监控的流程回放录制数据：初始化、时钟同步、每轮获取费率并分析，
+// 回放时钟经过1小时后更新结算周期和合约状态，通知内容输出到日志
+func runReplayCommand(dir string) {
+	replay, err := LoadReplay(dir)
+	if err != nil {
+		log.Fatalf("载入录制数据失败: %v", err)
+	}
+	localNow = replay.Now
+
+	config := LoadConfig()
+	health := NewHealthTracker()
+	exchanges := newReplayExchanges(replay, health)
+
+	monitor := NewMonitor("", 0.02, config, exchanges, health)
+	monitor.notify = func(message string) error {
+		log.Printf("回放通知:\n%s", message)
+		return nil
+	}
+
+	log.Printf("开始回放 %s，录制开始时间: %s", dir, replay.Now().Format("2006-01-02 15:04:05"))
+	if err := monitor.InitializeExchanges(); err != nil {
+		log.Fatalf("初始化失败: %v", err)
+	}
+	monitor.SyncClocks()
+
+	lastIntervalUpdate := replay.Now()
+	lastClockSync := replay.Now()
+	clockSyncInterval := time.Duration(config.ClockSyncIntervalMin) * time.Minute
+
+	cycles := 0
+	for replay.Pending() > 0 {
+		pending := replay.Pending()
+
+		monitor.CheckArbitrageOpportunities()
+		cycles++
+
+		now := replay.Now()
+		if now.Sub(lastIntervalUpdate) >= time.Hour {
+			monitor.UpdateFundingIntervals()
+			lastIntervalUpdate = now
+		}
+		if now.Sub(lastClockSync) >= clockSyncInterval {
+			monitor.SyncClocks()
+			lastClockSync = now
+		}
+
+		// 本轮没有用到任何录制数据，剩余的记录不会再被请求
+		if replay.Pending() == pending {
+			break
 		}
 	}
+
+	log.Printf("回放完成: %d 轮，回放至 %s，剩余 %d 条记录未使用，%d 个请求在录制数据中不存在",
+		cycles, replay.Now().Format("2006-01-02 15:04:05"), replay.Pending(), replay.Missed())
 }
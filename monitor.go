@@ -5,6 +5,7 @@ import (
 	"log"
 	"math"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -12,25 +13,47 @@ import (
 type Monitor struct {
 	webhookURL        string
 	threshold         float64
+	config            *Config
+	clock             *ClockSync
+	health            *HealthTracker
 	exchanges         []Exchange
 	lastNotifications map[string]time.Time // symbol -> last notification time
+	rolledCounts      map[string]int64     // exchange -> 下次结算时间过期后推算的累计次数
+	listedAt          map[string]time.Time // exchange_symbol -> 检测到上线的时间
+	borrow            borrowCache
+	transfer          transferCache
+	fees              feeCache
+	settled           settledCache
+	openInterest      openInterestCache
+	projection        ProjectionModel
+	schemaStates      map[string]*schemaState    // exchange_endpoint_field -> 字段告警状态
+	accounts          AccountClients             // 配置了API凭证的账户
+	notify            func(message string) error // 发送通知，未配置webhook时为nil
 	mu                sync.RWMutex
 }
 
-func NewMonitor(webhookURL string, threshold float64) *Monitor {
-	return &Monitor{
-		webhookURL: webhookURL,
-		threshold:  threshold,
-		exchanges: []Exchange{
-			NewBinanceExchange(),
-			NewOKXExchange(),
-			NewBybitExchange(),
-			NewMEXCExchange(),
-			NewBitgetExchange(),
-			NewGateExchange(),
-		},
+func NewMonitor(webhookURL string, threshold float64, config *Config, exchanges []Exchange, health *HealthTracker) *Monitor {
+	m := &Monitor{
+		webhookURL:        webhookURL,
+		threshold:         threshold,
+		config:            config,
+		clock:             NewClockSync(),
+		health:            health,
+		exchanges:         exchanges,
 		lastNotifications: make(map[string]time.Time),
+		rolledCounts:      make(map[string]int64),
+		listedAt:          make(map[string]time.Time),
+		schemaStates:      make(map[string]*schemaState),
+		projection:        newProjectionModel(config),
 	}
+
+	if webhookURL != "" {
+		m.notify = func(message string) error {
+			return SendWechatMessage(webhookURL, message)
+		}
+	}
+
+	return m
 }
 
 func (m *Monitor) InitializeExchanges() error {
@@ -60,6 +83,8 @@ func (m *Monitor) InitializeExchanges() error {
 		log.Printf("错误: %v", err)
 	}
 
+	m.refreshFeeRates()
+
 	return nil
 }
 
@@ -81,9 +106,58 @@ func (m *Monitor) UpdateFundingIntervals() {
 	}
 	wg.Wait()
 	log.Println("所有交易所结算周期和合约状态更新完成")
+
+	// 预测模型使用的本地历史平均费率随结算周期每小时重新加载，不在每轮检查中读取文件
+	if projection, ok := m.projection.(historyProjection); ok {
+		projection.RefreshHistory()
+	}
+
+	m.health.LogSummary()
+
+	m.handleContractEvents()
+	m.handleIntervalChanges()
+	m.handleSchemaChecks()
+	m.refreshFeeRates()
 }
 
 func (m *Monitor) CheckArbitrageOpportunities() {
+	exchangeDataMap := m.fetchExchangeData()
+
+	// OKX、Bitget等每轮获取费率时推算结算周期，需要每轮检查周期变化
+	m.handleIntervalChanges()
+	m.handleSchemaChecks()
+
+	// 分析套利机会
+	opportunities := m.analyzeArbitrage(exchangeDataMap)
+
+	// 本轮新用到的交易所+币种在后台加载历史平均费率，加载完成前回归到利率
+	if projection, ok := m.projection.(historyProjection); ok {
+		projection.LoadPendingHistory()
+	}
+
+	// 对通过阈值的机会检查盘口深度，深度过滤之后每个币种取最好的 PAIRS_PER_SYMBOL 对
+	if len(opportunities) > 0 {
+		opportunities = m.applyDepth(opportunities)
+		m.sortOpportunities(opportunities)
+		opportunities = m.topPairsPerSymbol(opportunities)
+	}
+
+	// 发送通知
+	if len(opportunities) > 0 {
+		m.sendNotifications(opportunities)
+	}
+
+	// 期现套利
+	if m.config.SpotCarryEnabled {
+		carry := m.analyzeSpotPerp(exchangeDataMap, m.fetchSpotTickers(), m.fetchBorrowRates())
+		if len(carry) > 0 {
+			m.sendSpotCarryNotifications(carry)
+		}
+	}
+}
+
+// fetchExchangeData 并发获取所有交易所的费率数据，推算过期的结算时间并按流动性过滤，exchange -> symbol -> 合约数据
+func (m *Monitor) fetchExchangeData() map[string]map[string]*ContractData {
 	// 并发获取所有交易所数据
 	type ExchangeData struct {
 		Name     string
@@ -117,16 +191,16 @@ func (m *Monitor) CheckArbitrageOpportunities() {
 			log.Printf("%s 获取数据失败: %v", data.Name, data.Error)
 			continue
 		}
+		if projection, ok := m.projection.(historyProjection); ok {
+			projection.RecordSettlements(data.Name, data.Contracts)
+		}
+		m.rollStaleFundingTimes(data.Name, data.Contracts)
+		m.applyOpenInterest(data.Name, data.Contracts)
+		m.filterLiquidity(data.Name, data.Contracts)
 		exchangeDataMap[data.Name] = data.Contracts
 	}
 
-	// 分析套利机会
-	opportunities := m.analyzeArbitrage(exchangeDataMap)
-
-	// 发送通知
-	if len(opportunities) > 0 {
-		m.sendNotifications(opportunities)
-	}
+	return exchangeDataMap
 }
 
 func (m *Monitor) analyzeArbitrage(exchangeData map[string]map[string]*ContractData) []ArbitrageOpportunity {
@@ -144,178 +218,347 @@ func (m *Monitor) analyzeArbitrage(exchangeData map[string]map[string]*ContractD
 
 	var opportunities []ArbitrageOpportunity
 
-	// 对每个币种分析
+	// 对每个币种分析，每对交易所保留超过阈值的最好时间窗口；检查盘口深度后再按币种取最好的 PAIRS_PER_SYMBOL 对
 	for symbol, exchanges := range symbolMap {
-		if len(exchanges) < 2 {
+		opportunities = append(opportunities, m.analyzeWindows(symbol, exchanges, nil)...)
+	}
+
+	// 按净收益率或USDT净收益排序
+	m.sortOpportunities(opportunities)
+
+	return opportunities
+}
+
+// topPairsPerSymbol 已排序的机会中每个币种取前 PAIRS_PER_SYMBOL 对交易所，在检查盘口深度之后调用，
+// 深度不足被过滤的组合不占用名额
+func (m *Monitor) topPairsPerSymbol(opportunities []ArbitrageOpportunity) []ArbitrageOpportunity {
+	counts := make(map[string]int)
+	var result []ArbitrageOpportunity
+
+	for _, opp := range opportunities {
+		if counts[opp.Symbol] >= m.config.PairsPerSymbol {
 			continue
 		}
+		counts[opp.Symbol]++
+		result = append(result, opp)
+	}
 
-		// 收集有效的交易所数据
-		var exchangeList []struct {
-			name     string
-			contract *ContractData
-		}
+	return result
+}
 
-		for exName, contract := range exchanges {
-			if contract.Price <= 0 || math.IsNaN(contract.FundingRate) || contract.NextFundingTime <= 0 {
-				continue
-			}
-			exchangeList = append(exchangeList, struct {
-				name     string
-				contract *ContractData
-			}{exName, contract})
-		}
+// symbolExchange 某币种在一个交易所的合约数据、观察期内预测的每次结算，以及与时间窗口无关、每轮只需计算一次的数据
+type symbolExchange struct {
+	name        string
+	contract    *ContractData
+	settlements []projectedSettlement // 按结算时间升序
+	cumulative  []float64             // cumulative[i] 为前i次结算的累计费率
+	bidPrice    float64               // 卖出成交价（买一）
+	askPrice    float64               // 买入成交价（卖一）
+	midPrice    float64
+	feeRate     float64 // 每次成交的手续费率
+	feeSource   string
+	newListing  bool
+}
+
+// settlementRange 在entryTimestamp的结算之后、targetTimestamp的结算之前（含）的结算下标范围 [from, to)
+func (ex *symbolExchange) settlementRange(entryTimestamp, targetTimestamp int64) (int, int) {
+	from := sort.Search(len(ex.settlements), func(i int) bool { return ex.settlements[i].Time > entryTimestamp })
+	to := sort.Search(len(ex.settlements), func(i int) bool { return ex.settlements[i].Time > targetTimestamp })
+	return from, to
+}
+
+// pairWindows 每对交易所超过阈值的最好时间窗口
+type pairWindows struct {
+	index map[string]int // 高费率交易所_低费率交易所 -> pairs中的下标
+	pairs []ArbitrageOpportunity
+}
+
+// windowStats -explain 使用：评估的交易所组合和时间窗口数，以及其中最好的一个（无论是否超过阈值）
+type windowStats struct {
+	evaluated int
+	best      *ArbitrageOpportunity
+}
+
+// analyzeWindows 分析某币种所有（开仓，平仓）时间窗口，每对交易所返回净收益超过阈值的最好时间窗口
+// 不在 TRADE_EXCHANGES 中的交易所不参与配对。stats不为nil时为所有窗口构建机会并统计（用于 -explain），否则只构建超过阈值的
+func (m *Monitor) analyzeWindows(symbol string, exchanges map[string]*ContractData, stats *windowStats) []ArbitrageOpportunity {
+	if len(exchanges) < 2 {
+		return nil
+	}
 
-		if len(exchangeList) < 2 {
+	// 收集有效的交易所数据，预测观察期内的每次结算，并计算与时间窗口无关的价格和手续费
+	horizonEnd := m.clock.NowMs() + int64(m.config.WindowHorizonHour*3600.0*1000.0)
+	var exchangeList []symbolExchange
+
+	for exName, contract := range exchanges {
+		if !m.config.CanTrade(exName) {
 			continue
 		}
-
-		// 收集所有不同的下次结算时间戳并排序
-		fundingTimestamps := make(map[int64]bool)
-		for _, ex := range exchangeList {
-			fundingTimestamps[ex.contract.NextFundingTime] = true
+		if contract.Price <= 0 || math.IsNaN(contract.FundingRate) || contract.NextFundingTime <= 0 {
+			continue
 		}
 
-		// 转换为切片并排序（从小到大）
-		var timestamps []int64
-		for ts := range fundingTimestamps {
-			timestamps = append(timestamps, ts)
+		settlements := m.projectSettlements(exName, contract, horizonEnd)
+		cumulative := make([]float64, len(settlements)+1)
+		for i, settlement := range settlements {
+			cumulative[i+1] = cumulative[i] + settlement.Rate
 		}
-		sort.Slice(timestamps, func(i, j int) bool {
-			return timestamps[i] < timestamps[j]
+		feeRate, feeSource := m.tradingFee(exName)
+
+		exchangeList = append(exchangeList, symbolExchange{
+			name:        exName,
+			contract:    contract,
+			settlements: settlements,
+			cumulative:  cumulative,
+			bidPrice:    contract.SellPrice(m.config.PriceSource),
+			askPrice:    contract.BuyPrice(m.config.PriceSource),
+			midPrice:    contract.MidPrice(m.config.PriceSource),
+			feeRate:     feeRate,
+			feeSource:   feeSource,
+			newListing:  m.isNewListing(exName, symbol),
 		})
+	}
 
-		// 对每个时间戳分析套利机会
-		for _, targetTimestamp := range timestamps {
-			opps := m.analyzeAtTimestamp(symbol, exchangeList, targetTimestamp, timestamps)
-			opportunities = append(opportunities, opps...)
+	if len(exchangeList) < 2 {
+		return nil
+	}
+
+	// 收集观察期内各交易所的所有结算时间戳，作为开仓和平仓的候选时间
+	fundingTimestamps := make(map[int64]bool)
+	for _, ex := range exchangeList {
+		for _, settlement := range ex.settlements {
+			fundingTimestamps[settlement.Time] = true
 		}
 	}
 
-	// 按净收益排序
-	sort.Slice(opportunities, func(i, j int) bool {
-		return opportunities[i].NetProfit > opportunities[j].NetProfit
+	// 转换为切片并排序（从小到大）
+	var timestamps []int64
+	for ts := range fundingTimestamps {
+		timestamps = append(timestamps, ts)
+	}
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i] < timestamps[j]
 	})
 
-	return opportunities
+	// 立即开仓或在某次结算后开仓，在之后的某次结算后平仓
+	best := &pairWindows{index: make(map[string]int)}
+	entries := append([]int64{0}, timestamps...)
+	for _, entryTimestamp := range entries {
+		for _, targetTimestamp := range timestamps {
+			if targetTimestamp <= entryTimestamp {
+				continue
+			}
+			m.analyzeAtTimestamp(symbol, exchangeList, entryTimestamp, targetTimestamp, best, stats)
+		}
+	}
+
+	return best.pairs
 }
 
-// analyzeAtTimestamp 分析在特定时间戳的套利机会
-func (m *Monitor) analyzeAtTimestamp(symbol string, exchangeList []struct {
-	name     string
-	contract *ContractData
-}, targetTimestamp int64, allTimestamps []int64) []ArbitrageOpportunity {
-	
-	var opportunities []ArbitrageOpportunity
-	currentTime := time.Now().Unix() * 1000 // 转换为毫秒
+// analyzeAtTimestamp 分析在entryTimestamp的结算之后开仓、在targetTimestamp的结算之后平仓的每对交易所
+// entryTimestamp为0表示立即开仓。先按累计费率、价差和手续费算出净收益，超过阈值时才构建机会、估算仓位，
+// 并与该对交易所已有的时间窗口比较，保留更好的一个
+func (m *Monitor) analyzeAtTimestamp(symbol string, exchangeList []symbolExchange, entryTimestamp, targetTimestamp int64, best *pairWindows, stats *windowStats) {
+	currentTime := m.clock.NowMs() // 按交易所服务器时间校正后的当前时间（毫秒）
 
 	// 计算到目标时间戳的时间差（小时）
 	timeToTarget := float64(targetTimestamp-currentTime) / (1000.0 * 3600.0)
 	if timeToTarget <= 0 {
-		return opportunities // 时间戳已过期
+		return // 时间戳已过期
+	}
+
+	// 开仓前的时间（小时）和持仓时长（小时）
+	timeToEntry := 0.0
+	if entryTimestamp > currentTime {
+		timeToEntry = float64(entryTimestamp-currentTime) / (1000.0 * 3600.0)
+	}
+	holdingHours := timeToTarget - timeToEntry
+
+	// 每个交易所在该时间窗口内累计的结算（考虑费率上下限、封顶后缩短的周期和预测模型），只累计开仓之后、目标时间之前（含）的结算
+	// 下次结算晚于目标时间的交易所不会结算，累计费率为0
+	type windowRate struct {
+		ex          *symbolExchange
+		from, to    int // 窗口内结算在settlements中的下标范围 [from, to)
+		accumulated float64
 	}
 
-	// 为每个交易所计算在目标时间戳时的累计费率
-	type ExchangeRate struct {
-		name              string
-		price             float64
-		originalRate      float64
-		accumulatedRate   float64 // 到目标时间的累计费率
-		nextFundingTime   int64
-		fundingInterval   float64
-		settlementsCount  int // 结算次数
+	rates := make([]windowRate, len(exchangeList))
+	for i := range exchangeList {
+		ex := &exchangeList[i]
+		from, to := ex.settlementRange(entryTimestamp, targetTimestamp)
+		rates[i] = windowRate{ex: ex, from: from, to: to, accumulated: ex.cumulative[to] - ex.cumulative[from]}
 	}
 
-	var rates []ExchangeRate
+	baseThreshold := m.getThreshold()
 
-	for _, ex := range exchangeList {
-		accumulatedRate := 0.0
-		settlementsCount := 0
-
-		if ex.contract.NextFundingTime <= targetTimestamp {
-			// 该交易所会在目标时间前结算
-			// 计算从现在到目标时间会结算几次
-			intervalMs := ex.contract.FundingIntervalHour * 3600.0 * 1000.0
-			
-			// 计算结算次数
-			if intervalMs > 0 {
-				// 从下次结算时间到目标时间的时间差
-				timeDiff := float64(targetTimestamp - ex.contract.NextFundingTime)
-				settlementsCount = 1 + int(timeDiff/intervalMs) // 至少结算一次
-				
-				// 累计费率 = 单次费率 × 结算次数
-				accumulatedRate = ex.contract.FundingRate * float64(settlementsCount)
+	// 评估每一对交易所：累计费率较高的一方做空，较低的一方做多
+	// 费率最极端的交易所不一定能用（盘口深度不足等），其他组合作为备选
+	for i := 0; i < len(rates)-1; i++ {
+		for j := i + 1; j < len(rates); j++ {
+			highRate, lowRate := &rates[i], &rates[j]
+			if highRate.accumulated < lowRate.accumulated {
+				highRate, lowRate = lowRate, highRate
 			}
-		} else {
-			// 该交易所在目标时间前不会结算，费率为0
-			accumulatedRate = 0.0
-			settlementsCount = 0
-		}
+			high, low := highRate.ex, lowRate.ex
 
-		rates = append(rates, ExchangeRate{
-			name:             ex.name,
-			price:            ex.contract.Price,
-			originalRate:     ex.contract.FundingRate,
-			accumulatedRate:  accumulatedRate,
-			nextFundingTime:  ex.contract.NextFundingTime,
-			fundingInterval:  ex.contract.FundingIntervalHour,
-			settlementsCount: settlementsCount,
-		})
+			// 计算可成交价差比：在低费率方按卖一价买入，在高费率方按买一价卖出
+			priceSpread := (low.askPrice - high.bidPrice) / high.bidPrice
+
+			// 两条腿各开仓、平仓一次，共4次成交的手续费
+			feeCost := 2*high.feeRate + 2*low.feeRate
+
+			// 计算扣除价差和手续费后的净收益
+			fundingEdge := highRate.accumulated - lowRate.accumulated
+			netProfit := fundingEdge - priceSpread - feeCost
+
+			threshold := baseThreshold
+			if (high.newListing || low.newListing) && m.config.NewListingThreshold > 0 {
+				threshold = m.config.NewListingThreshold
+			}
+			if netProfit <= threshold && stats == nil {
+				continue
+			}
+
+			// 格式化开仓和目标时间为 UTC+8
+			targetTime := time.Unix(targetTimestamp/1000, 0).In(time.FixedZone("CST", 8*3600))
+			var entryTime time.Time
+			if entryTimestamp > 0 {
+				entryTime = time.Unix(entryTimestamp/1000, 0).In(time.FixedZone("CST", 8*3600))
+			}
+
+			opp := ArbitrageOpportunity{
+				Symbol:                symbol,
+				HighRateExchange:      high.name,
+				LowRateExchange:       low.name,
+				HighRate:              high.contract.FundingRate,
+				LowRate:               low.contract.FundingRate,
+				HighPrice:             high.contract.PriceFor(m.config.PriceSource),
+				LowPrice:              low.contract.PriceFor(m.config.PriceSource),
+				HighBasis:             high.contract.Basis(),
+				LowBasis:              low.contract.Basis(),
+				HighBidPrice:          high.bidPrice,
+				LowAskPrice:           low.askPrice,
+				PriceSpread:           priceSpread,
+				MidPriceSpread:        (low.midPrice - high.midPrice) / high.midPrice, // 中间价价差比，仅作参考
+				NetProfit:             netProfit,
+				FundingEdge:           fundingEdge,
+				FeeOrderType:          m.config.FeeOrderType,
+				HighFeeRate:           high.feeRate,
+				LowFeeRate:            low.feeRate,
+				HighFeeSource:         high.feeSource,
+				LowFeeSource:          low.feeSource,
+				FeeCost:               feeCost,
+				Threshold:             threshold,
+				HighRateIntervalH:     high.contract.FundingIntervalHour,
+				LowRateIntervalH:      low.contract.FundingIntervalHour,
+				TargetTimestamp:       targetTimestamp,
+				TargetTime:            targetTime,
+				TimeToTarget:          timeToTarget,
+				EntryTimestamp:        entryTimestamp,
+				EntryTime:             entryTime,
+				TimeToEntry:           timeToEntry,
+				HoldingHours:          holdingHours,
+				HighAccumulatedRate:   highRate.accumulated,
+				LowAccumulatedRate:    lowRate.accumulated,
+				HighSettlements:       highRate.to - highRate.from,
+				HighProjection:        high.settlements[highRate.from:highRate.to],
+				LowProjection:         low.settlements[lowRate.from:lowRate.to],
+				LowSettlements:        lowRate.to - lowRate.from,
+				HighAtCap:             high.contract.AtFundingCap(),
+				LowAtCap:              low.contract.AtFundingCap(),
+				HighPrevIntervalH:     m.recentPrevInterval(high.contract),
+				LowPrevIntervalH:      m.recentPrevInterval(low.contract),
+				HighIntervalChangedAt: high.contract.IntervalChangedAt,
+				LowIntervalChangedAt:  low.contract.IntervalChangedAt,
+				HighRatePredicted:     high.contract.FundingRatePredicted,
+				LowRatePredicted:      low.contract.FundingRatePredicted,
+				HighPrevRate:          high.contract.PrevFundingRate,
+				LowPrevRate:           low.contract.PrevFundingRate,
+				HighHasPrevRate:       high.contract.PrevFundingTime > 0,
+				LowHasPrevRate:        low.contract.PrevFundingTime > 0,
+				HighNewListing:        high.newListing,
+				LowNewListing:         low.newListing,
+				Timestamp:             localNow(),
+			}
+
+			// 按预算估算建议仓位，通过阈值后再按盘口深度修正
+			m.applySizing(&opp, nil, nil)
+
+			if stats != nil {
+				stats.evaluated++
+				if stats.best == nil || m.betterOpportunity(&opp, stats.best) {
+					windowBest := opp
+					stats.best = &windowBest
+				}
+			}
+			if netProfit <= threshold {
+				continue
+			}
+
+			key := high.name + "_" + low.name
+			if index, ok := best.index[key]; ok {
+				if m.betterOpportunity(&opp, &best.pairs[index]) {
+					best.pairs[index] = opp
+				}
+				continue
+			}
+			best.index[key] = len(best.pairs)
+			best.pairs = append(best.pairs, opp)
+		}
 	}
+}
 
-	// 找出最高和最低累计费率
-	if len(rates) < 2 {
-		return opportunities
+// filterLiquidity 按成交额和持仓价值过滤流动性不足的合约
+func (m *Monitor) filterLiquidity(exchangeName string, contracts map[string]*ContractData) {
+	for symbol, contract := range contracts {
+		if !m.config.Liquidity.Allow(exchangeName, contract) {
+			delete(contracts, symbol)
+		}
 	}
+}
 
-	// 按累计费率排序
-	sort.Slice(rates, func(i, j int) bool {
-		return rates[i].accumulatedRate < rates[j].accumulatedRate
-	})
+// rollStaleFundingTimes 缓存的下次结算时间已过期时（如Gate每小时才刷新一次），按结算周期向后推算
+func (m *Monitor) rollStaleFundingTimes(exchangeName string, contracts map[string]*ContractData) {
+	now := m.clock.NowMs()
+	rolled := 0
+
+	for _, contract := range contracts {
+		nextFundingTime, ok := rollForwardFundingTime(contract.NextFundingTime, contract.FundingIntervalHour, now)
+		if ok {
+			contract.NextFundingTime = nextFundingTime
+			contract.FundingTimeRolled = true
+			rolled++
+		}
+	}
 
-	lowRate := rates[0]
-	highRate := rates[len(rates)-1]
+	if rolled == 0 {
+		return
+	}
 
-	// 计算价差比
-	priceSpread := (lowRate.price - highRate.price) / highRate.price
+	m.mu.Lock()
+	m.rolledCounts[exchangeName] += int64(rolled)
+	total := m.rolledCounts[exchangeName]
+	m.mu.Unlock()
 
-	// 计算净收益
-	netProfit := (highRate.accumulatedRate - lowRate.accumulatedRate) - priceSpread
+	log.Printf("%s 有 %d 个合约的下次结算时间已过期，已按结算周期推算（累计 %d 次）", exchangeName, rolled, total)
+}
 
-	// 统一阈值 0.4%
-	threshold := m.threshold
-	if threshold == 0 {
-		threshold = 0.004 // 默认0.4%
+// getThreshold 统一阈值，未设置时默认0.4%
+func (m *Monitor) getThreshold() float64 {
+	if m.threshold == 0 {
+		return 0.004
 	}
+	return m.threshold
+}
 
-	if netProfit > threshold {
-		// 格式化目标时间为 UTC+8
-		targetTime := time.Unix(targetTimestamp/1000, 0).In(time.FixedZone("CST", 8*3600))
-		
-		opportunities = append(opportunities, ArbitrageOpportunity{
-			Symbol:              symbol,
-			HighRateExchange:    highRate.name,
-			LowRateExchange:     lowRate.name,
-			HighRate:            highRate.originalRate,
-			LowRate:             lowRate.originalRate,
-			HighPrice:           highRate.price,
-			LowPrice:            lowRate.price,
-			PriceSpread:         priceSpread,
-			NetProfit:           netProfit,
-			HighRateIntervalH:   highRate.fundingInterval,
-			LowRateIntervalH:    lowRate.fundingInterval,
-			TargetTimestamp:     targetTimestamp,
-			TargetTime:          targetTime,
-			TimeToTarget:        timeToTarget,
-			HighAccumulatedRate: highRate.accumulatedRate,
-			LowAccumulatedRate:  lowRate.accumulatedRate,
-			HighSettlements:     highRate.settlementsCount,
-			LowSettlements:      lowRate.settlementsCount,
-			Timestamp:           time.Now(),
-		})
+// getExchange 按名称查找交易所
+func (m *Monitor) getExchange(name string) Exchange {
+	for _, exchange := range m.exchanges {
+		if exchange.Name() == name {
+			return exchange
+		}
 	}
-
-	return opportunities
+	return nil
 }
 
 // getThresholdByInterval 统一阈值为1%
@@ -324,13 +567,13 @@ func (m *Monitor) getThresholdByInterval(interval float64) float64 {
 }
 
 func (m *Monitor) sendNotifications(opportunities []ArbitrageOpportunity) {
-	if m.webhookURL == "" {
+	if m.notify == nil {
 		log.Println("未配置微信webhook，跳过通知")
 		return
 	}
 
 	// 过滤出需要通知的机会（1小时内未通知过的）
-	now := time.Now()
+	now := localNow()
 	var validOpportunities []ArbitrageOpportunity
 	
 	m.mu.Lock()
@@ -356,71 +599,237 @@ func (m *Monitor) sendNotifications(opportunities []ArbitrageOpportunity) {
 	if count > 5 {
 		count = 5
 	}
-	
-	// 获取阈值
-	threshold := m.threshold
-	if threshold == 0 {
-		threshold = 0.004 // 默认0.4%
+
+	// 只为发送的机会查询充提状态
+	if m.config.TransferCheckEnabled {
+		m.applyTransfer(validOpportunities[:count])
 	}
 
+	// 行情接口未提供上一期费率时，只为发送的机会查询历史接口
+	m.applyPrevRates(validOpportunities[:count])
+	
 	message := fmt.Sprintf("🔔 发现 %d 个套利机会\n\n", len(validOpportunities))
 	
 	for i := 0; i < count; i++ {
 		opp := validOpportunities[i]
 		
 		message += fmt.Sprintf("【%s】\n", opp.Symbol)
-		message += fmt.Sprintf("目标时间: %s (%.2f小时后)\n", 
-			opp.TargetTime.Format("01-02 15:04"), opp.TimeToTarget)
-		message += fmt.Sprintf("净收益: %.4f%% (阈值: %.2f%%)\n", opp.NetProfit*100, threshold*100)
+		if opp.EntryTimestamp > 0 {
+			message += fmt.Sprintf("开仓时间: %s 结算后 (%.2f小时后)\n",
+				opp.EntryTime.Format("01-02 15:04"), opp.TimeToEntry)
+		} else {
+			message += "开仓时间: 立即\n"
+		}
+		message += fmt.Sprintf("目标时间: %s 结算后平仓 (%.2f小时后, 持仓%.2f小时)\n",
+			opp.TargetTime.Format("01-02 15:04"), opp.TimeToTarget, opp.HoldingHours)
+		message += fmt.Sprintf("净收益: %.4f%% (阈值: %.2f%%)\n", opp.NetProfit*100, opp.Threshold*100)
+		message += fmt.Sprintf("费差 %.4f%% - 价差 %.4f%% - 手续费 %.4f%%\n",
+			opp.FundingEdge*100, opp.PriceSpread*100, opp.FeeCost*100)
+		message += fmt.Sprintf("手续费(%s×2): %s %.4f%%%s / %s %.4f%%%s\n", feeOrderText(opp.FeeOrderType),
+			opp.HighRateExchange, opp.HighFeeRate*100, feeSourceTag(opp.HighFeeSource),
+			opp.LowRateExchange, opp.LowFeeRate*100, feeSourceTag(opp.LowFeeSource))
+		message += fmt.Sprintf("建议仓位: %.0f USDT/腿 (限制: %s)\n", opp.RecommendedNotional, opp.NotionalLimit)
+		message += fmt.Sprintf("预期收益: 资金费 %.2f - 价差 %.2f - 手续费 %.2f = %.2f USDT\n",
+			opp.FundingIncomeUSDT, opp.SpreadCostUSDT, opp.FeeUSDT, opp.NetProfitUSDT)
+		message += fmt.Sprintf("年化: %.1f%% 保证金收益率: %.2f%% (杠杆 %gx/%gx, 保证金 %.0f USDT)\n",
+			opp.APR*100, opp.ReturnOnMargin*100, opp.HighLeverage, opp.LowLeverage, opp.MarginUSDT)
+		if opp.HighNewListing || opp.LowNewListing {
+			message += fmt.Sprintf("新上线: %s\n", newListingText(opp))
+		}
 		
 		// 高费率方
 		if opp.HighSettlements > 0 {
-			message += fmt.Sprintf("高费率: %s %.4f%% × %d次 = %.4f%%\n", 
-				opp.HighRateExchange, opp.HighRate*100, 
-				opp.HighSettlements, opp.HighAccumulatedRate*100)
+			message += fmt.Sprintf("高费率: %s %s%s%s\n",
+				opp.HighRateExchange, accumulatedText(opp.HighRate, opp.HighProjection, opp.HighAccumulatedRate),
+				predictedTag(opp.HighRatePredicted),
+				fundingNote(opp.HighAtCap, opp.HighPrevIntervalH, opp.HighRateIntervalH, opp.HighIntervalChangedAt))
+			if !sameProjectedRate(opp.HighProjection) {
+				message += fmt.Sprintf("  预测: %s\n", projectionText(opp.HighProjection))
+			}
 		} else {
 			message += fmt.Sprintf("高费率: %s 0%% (未结算)\n", opp.HighRateExchange)
 		}
 		
 		// 低费率方
 		if opp.LowSettlements > 0 {
-			message += fmt.Sprintf("低费率: %s %.4f%% × %d次 = %.4f%%\n", 
-				opp.LowRateExchange, opp.LowRate*100, 
-				opp.LowSettlements, opp.LowAccumulatedRate*100)
+			message += fmt.Sprintf("低费率: %s %s%s%s\n",
+				opp.LowRateExchange, accumulatedText(opp.LowRate, opp.LowProjection, opp.LowAccumulatedRate),
+				predictedTag(opp.LowRatePredicted),
+				fundingNote(opp.LowAtCap, opp.LowPrevIntervalH, opp.LowRateIntervalH, opp.LowIntervalChangedAt))
+			if !sameProjectedRate(opp.LowProjection) {
+				message += fmt.Sprintf("  预测: %s\n", projectionText(opp.LowProjection))
+			}
 		} else {
 			message += fmt.Sprintf("低费率: %s 0%% (未结算)\n", opp.LowRateExchange)
 		}
 		
-		message += fmt.Sprintf("价差比: %.4f%%\n", opp.PriceSpread*100)
-		message += fmt.Sprintf("价格: %.4f / %.4f\n", opp.HighPrice, opp.LowPrice)
+		if opp.HighHasPrevRate || opp.LowHasPrevRate {
+			message += fmt.Sprintf("上期费率: %s / %s\n",
+				prevRateText(opp.HighHasPrevRate, opp.HighPrevRate), prevRateText(opp.LowHasPrevRate, opp.LowPrevRate))
+		}
+		message += fmt.Sprintf("价差比: %.4f%% (中间价: %.4f%%)\n", opp.PriceSpread*100, opp.MidPriceSpread*100)
+		message += fmt.Sprintf("成交价: 卖 %.4f / 买 %.4f\n", opp.HighBidPrice, opp.LowAskPrice)
+		message += fmt.Sprintf("价格(%s): %.4f / %.4f\n", m.config.PriceSource, opp.HighPrice, opp.LowPrice)
+		message += fmt.Sprintf("基差: %.4f%% / %.4f%%\n", opp.HighBasis*100, opp.LowBasis*100)
+		if opp.DepthChecked {
+			if opp.TargetFillable {
+				message += fmt.Sprintf("深度(%.0fU): 成交价差 %.4f%%, 净收益 %.4f%%\n",
+					opp.TargetNotional, opp.FillSpread*100, opp.FillNetProfit*100)
+			} else {
+				message += fmt.Sprintf("深度(%.0fU): 盘口不足\n", opp.TargetNotional)
+			}
+			message += fmt.Sprintf("最大可承载: %.0f USDT\n", opp.MaxNotional)
+		}
+		if opp.HighTransfer != "" {
+			message += fmt.Sprintf("USDT转入: %s / %s\n", opp.HighTransfer, opp.LowTransfer)
+			message += fmt.Sprintf("%s转入: %s / %s\n", strings.TrimSuffix(opp.Symbol, "USDT"), opp.HighAssetTransfer, opp.LowAssetTransfer)
+		}
 		message += "\n"
 	}
 
-	if err := SendWechatMessage(m.webhookURL, message); err != nil {
+	message += "注: 标注(预估)的费率为实时估算值，结算前仍可能变化\n"
+	if m.config.ProjectionModel != ProjectionConstant {
+		message += fmt.Sprintf("注: 下次结算之后的费率按 %s 模型预测，累计费率为预测路径之和\n", m.config.ProjectionModel)
+	}
+
+	if err := m.notify(message); err != nil {
 		log.Printf("发送微信通知失败: %v", err)
 	} else {
 		log.Printf("已发送微信通知，包含 %d 个套利机会", count)
 	}
 }
 
+// fundingNote 通知中的费率备注：是否触及上下限、结算周期近期是否变化
+func fundingNote(atCap bool, prevInterval, interval float64, changedAt int64) string {
+	note := ""
+	if atCap {
+		note += " [触及上下限]"
+	}
+	if prevInterval > 0 {
+		hoursAgo := localNow().Sub(time.UnixMilli(changedAt)).Hours()
+		note += fmt.Sprintf(" [周期 %.0fh→%.0fh, %.1f小时前]", prevInterval, interval, hoursAgo)
+	}
+	return note
+}
+
+// accumulatedText 通知中的累计费率算式
+// 每次结算的预测费率相同时显示为 单次费率 × 次数，单次费率按上下限截断后与原始费率不同时注明原始费率；
+// 否则（预测模型给出的费率逐次变化）只显示预测的累计费率，预测路径另起一行
+func accumulatedText(rawRate float64, projection []projectedSettlement, accumulated float64) string {
+	if !sameProjectedRate(projection) {
+		return fmt.Sprintf("预测累计 %.4f%% (%d次)", accumulated*100, len(projection))
+	}
+
+	rate := projection[0].Rate
+	text := fmt.Sprintf("%.4f%% × %d次 = %.4f%%", rate*100, len(projection), accumulated*100)
+	if rate != rawRate {
+		text += fmt.Sprintf(" (原始费率 %.4f%%)", rawRate*100)
+	}
+	return text
+}
+
+// sameProjectedRate 持仓期间每次结算的预测费率是否相同
+func sameProjectedRate(projection []projectedSettlement) bool {
+	for _, settlement := range projection {
+		if settlement.Rate != projection[0].Rate {
+			return false
+		}
+	}
+	return true
+}
+
+// predictedTag 实时预估费率的标注
+func predictedTag(predicted bool) string {
+	if predicted {
+		return "(预估)"
+	}
+	return "(已锁定)"
+}
+
+// prevRateText 上一期费率文本，交易所未提供时显示为 -
+func prevRateText(hasPrevRate bool, prevRate float64) string {
+	if !hasPrevRate {
+		return "-"
+	}
+	return fmt.Sprintf("%.4f%%", prevRate*100)
+}
+
 type ArbitrageOpportunity struct {
-	Symbol              string
-	HighRateExchange    string
-	LowRateExchange     string
-	HighRate            float64   // 原始费率
-	LowRate             float64   // 原始费率
-	HighPrice           float64
-	LowPrice            float64
-	PriceSpread         float64
-	NetProfit           float64
-	HighRateIntervalH   float64   // 结算周期（小时）
-	LowRateIntervalH    float64   // 结算周期（小时）
-	TargetTimestamp     int64     // 目标结算时间戳（毫秒）
-	TargetTime          time.Time // 目标结算时间
-	TimeToTarget        float64   // 距离目标时间（小时）
-	HighAccumulatedRate float64   // 高费率方累计费率
-	LowAccumulatedRate  float64   // 低费率方累计费率
-	HighSettlements     int       // 高费率方结算次数
-	LowSettlements      int       // 低费率方结算次数
-	Timestamp           time.Time
+	Symbol                string
+	HighRateExchange      string
+	LowRateExchange       string
+	HighRate              float64 // 原始费率
+	LowRate               float64 // 原始费率
+	HighPrice             float64
+	LowPrice              float64
+	HighBasis             float64 // 高费率方基差（标记价格相对指数价格）
+	LowBasis              float64 // 低费率方基差
+	HighBidPrice          float64 // 高费率方卖出成交价（买一）
+	LowAskPrice           float64 // 低费率方买入成交价（卖一）
+	PriceSpread           float64 // 可成交价差比（卖一买入、买一卖出）
+	MidPriceSpread        float64 // 中间价价差比
+	NetProfit             float64
+	FundingEdge           float64   // 资金费差：高费率方累计费率 - 低费率方累计费率，未扣除价差和手续费
+	FeeOrderType          string    // 手续费按吃单（taker）还是挂单（maker）成交计算
+	HighFeeRate           float64   // 高费率方每次成交的手续费率
+	LowFeeRate            float64   // 低费率方每次成交的手续费率
+	HighFeeSource         string    // 高费率方手续费率来源：account / static
+	LowFeeSource          string    // 低费率方手续费率来源
+	FeeCost               float64   // 两条腿开仓和平仓共4次成交的手续费，NetProfit已扣除
+	RecommendedNotional   float64   // 建议每条腿的名义价值（USDT），受单笔预算、资金×杠杆和盘口深度限制
+	NotionalLimit         string    // 限制建议仓位的因素
+	FundingIncomeUSDT     float64   // 建议仓位的资金费收入（USDT）
+	SpreadCostUSDT        float64   // 建议仓位的价差成本（USDT）
+	FeeUSDT               float64   // 建议仓位的手续费（USDT）
+	NetProfitUSDT         float64   // 建议仓位扣除价差和手续费后的净收益（USDT）
+	HighLeverage          float64   // 高费率方使用的杠杆
+	LowLeverage           float64   // 低费率方使用的杠杆
+	MarginUSDT            float64   // 建议仓位两条腿占用的保证金（USDT）
+	ReturnOnMargin        float64   // 保证金收益率：净收益 / 两条腿占用的保证金
+	APR                   float64   // 年化收益率：净收益按持有到目标时间的时长折算
+	Threshold             float64   // 本机会适用的净收益阈值
+	HighRateIntervalH     float64   // 结算周期（小时）
+	LowRateIntervalH      float64   // 结算周期（小时）
+	TargetTimestamp       int64     // 目标结算时间戳（毫秒）
+	TargetTime            time.Time // 目标结算时间
+	TimeToTarget          float64   // 距离目标时间（小时）
+	EntryTimestamp        int64     // 建议开仓的结算时间戳（毫秒），在该次结算之后开仓，0表示立即开仓
+	EntryTime             time.Time // 建议开仓时间，立即开仓时为零值
+	TimeToEntry           float64   // 距离开仓时间（小时）
+	HoldingHours          float64   // 持仓时长（小时）
+	DepthChecked          bool      // 是否已检查盘口深度
+	TargetNotional        float64   // 每条腿的目标名义价值（USDT）
+	HighFillPrice         float64   // 高费率方卖出目标名义价值的成交均价
+	LowFillPrice          float64   // 低费率方买入目标名义价值的成交均价
+	FillSpread            float64   // 按成交均价计算的价差比
+	TargetFillable        bool      // 两边盘口是否足以成交目标名义价值
+	FillNetProfit         float64   // 按成交均价计算的净收益
+	MaxNotional           float64   // 净收益仍高于阈值的最大名义价值（USDT）
+	HighAccumulatedRate   float64   // 高费率方累计费率
+	LowAccumulatedRate    float64   // 低费率方累计费率
+	HighSettlements       int       // 高费率方结算次数
+	LowSettlements        int       // 低费率方结算次数
+	HighAtCap             bool      // 高费率方费率已触及上下限
+	LowAtCap              bool      // 低费率方费率已触及上下限
+	HighPrevIntervalH     float64   // 高费率方变化前的结算周期，0表示未变化
+	LowPrevIntervalH      float64   // 低费率方变化前的结算周期，0表示未变化
+	HighIntervalChangedAt int64     // 高费率方结算周期变化时间（毫秒）
+	LowIntervalChangedAt  int64     // 低费率方结算周期变化时间（毫秒）
+	HighRatePredicted     bool      // 高费率方费率是否为实时预估值
+	LowRatePredicted      bool      // 低费率方费率是否为实时预估值
+	HighPrevRate          float64   // 高费率方上一期已结算费率
+	LowPrevRate           float64   // 低费率方上一期已结算费率
+	HighHasPrevRate       bool
+	LowHasPrevRate        bool
+	HighNewListing        bool   // 高费率方为新上线合约
+	LowNewListing         bool   // 低费率方为新上线合约
+	HighTransfer          string // USDT转入高费率方交易所的路线
+	LowTransfer           string // USDT转入低费率方交易所的路线
+	HighAssetTransfer     string // 基础币种转入高费率方交易所的路线
+	LowAssetTransfer      string // 基础币种转入低费率方交易所的路线
+	Timestamp             time.Time
+
+	// 持仓期间每次结算的预测费率，下次结算之后按 PROJECTION_MODEL 预测
+	HighProjection []projectedSettlement
+	LowProjection  []projectedSettlement
 }
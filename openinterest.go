@@ -0,0 +1,85 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// openInterestRefresh 按币种查询的持仓量的缓存时间
+const openInterestRefresh = 10 * time.Minute
+
+// openInterestFetchesPerRound 每轮最多查询的币种数，避免触发交易所限频
+const openInterestFetchesPerRound = 20
+
+// openInterestCache 按币种查询到的持仓量
+type openInterestCache struct {
+	coins     map[string]float64   // exchange_symbol -> 持仓量（币）
+	fetchedAt map[string]time.Time // exchange_symbol -> 查询时间
+	mu        sync.Mutex
+}
+
+// applyOpenInterest 为行情接口不含持仓量的交易所（币安）按币种查询持仓量并填入OpenInterestUSDT
+// 只在配置了持仓价值下限时查询，只查询通过成交额过滤的合约；每轮最多查询 openInterestFetchesPerRound 个，
+// 从未查询过的优先，其次是最久未更新的。尚未查询到持仓量的合约本轮按流动性不足过滤
+func (m *Monitor) applyOpenInterest(exchangeName string, contracts map[string]*ContractData) {
+	market, ok := m.getExchange(exchangeName).(OpenInterestMarket)
+	if !ok || m.config.Liquidity.MinOpenInterestFor(exchangeName) <= 0 {
+		return
+	}
+
+	m.openInterest.mu.Lock()
+	if m.openInterest.coins == nil {
+		m.openInterest.coins = make(map[string]float64)
+		m.openInterest.fetchedAt = make(map[string]time.Time)
+	}
+
+	var stale []string
+	for symbol, contract := range contracts {
+		if contract.QuoteVolume24h < m.config.Liquidity.MinVolumeFor(exchangeName) {
+			continue
+		}
+		if fetchedAt, ok := m.openInterest.fetchedAt[exchangeName+"_"+symbol]; !ok || localNow().Sub(fetchedAt) >= openInterestRefresh {
+			stale = append(stale, symbol)
+		}
+	}
+
+	// 零值时间排在最前，即从未查询过的优先
+	sort.Slice(stale, func(i, j int) bool {
+		return m.openInterest.fetchedAt[exchangeName+"_"+stale[i]].Before(m.openInterest.fetchedAt[exchangeName+"_"+stale[j]])
+	})
+	m.openInterest.mu.Unlock()
+
+	if len(stale) > openInterestFetchesPerRound {
+		stale = stale[:openInterestFetchesPerRound]
+	}
+
+	var wg sync.WaitGroup
+	for _, symbol := range stale {
+		wg.Add(1)
+		go func(symbol string) {
+			defer wg.Done()
+			coins, err := market.FetchOpenInterest(symbol)
+			if err != nil {
+				log.Printf("%s 查询 %s 持仓量失败: %v", exchangeName, symbol, err)
+				return
+			}
+
+			m.openInterest.mu.Lock()
+			m.openInterest.coins[exchangeName+"_"+symbol] = coins
+			m.openInterest.fetchedAt[exchangeName+"_"+symbol] = localNow()
+			m.openInterest.mu.Unlock()
+		}(symbol)
+	}
+	wg.Wait()
+
+	m.openInterest.mu.Lock()
+	defer m.openInterest.mu.Unlock()
+
+	for symbol, contract := range contracts {
+		if coins, ok := m.openInterest.coins[exchangeName+"_"+symbol]; ok {
+			contract.OpenInterestUSDT = coins * contract.PriceFor(PriceSourceMark)
+		}
+	}
+}
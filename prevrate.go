@@ -0,0 +1,128 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// prevRateLookback 查询上一期费率时回溯的时间，覆盖最长的结算周期
+const prevRateLookback = 48 * time.Hour
+
+// settledCache 从历史资金费率接口查询到的最近一次结算
+type settledCache struct {
+	records map[string]FundingRecord  // exchange_symbol -> 最近一次已结算的费率
+	pending map[string]settledRequest // exchange_symbol -> 待查询
+	loading bool                      // 是否正在后台查询pending
+	mu      sync.Mutex
+}
+
+// settledRequest 待查询上一期费率的交易所和币种
+type settledRequest struct {
+	exchange string
+	symbol   string
+}
+
+// applyPrevRates 行情接口未提供上一期费率的交易所（如币安、Bybit），从缓存的历史资金费率中取最近一次结算
+// 缓存中没有或已过期的在后台查询，不阻塞通知，查询完成前显示为 -
+func (m *Monitor) applyPrevRates(opportunities []ArbitrageOpportunity) {
+	for i := range opportunities {
+		opp := &opportunities[i]
+
+		if !opp.HighHasPrevRate {
+			if record, ok := m.lastSettled(opp.HighRateExchange, opp.Symbol, opp.HighRateIntervalH); ok {
+				opp.HighPrevRate, opp.HighHasPrevRate = record.FundingRate, true
+			}
+		}
+		if !opp.LowHasPrevRate {
+			if record, ok := m.lastSettled(opp.LowRateExchange, opp.Symbol, opp.LowRateIntervalH); ok {
+				opp.LowPrevRate, opp.LowHasPrevRate = record.FundingRate, true
+			}
+		}
+	}
+
+	m.loadPendingSettled()
+}
+
+// lastSettled 缓存中交易所某币种最近一次已结算的费率，缓存到下一次结算之前
+// 没有或已过期时记为待查询，返回false
+func (m *Monitor) lastSettled(exchange, symbol string, intervalHour float64) (FundingRecord, bool) {
+	key := exchange + "_" + symbol
+	now := m.clock.NowMs()
+
+	m.settled.mu.Lock()
+	defer m.settled.mu.Unlock()
+
+	record, ok := m.settled.records[key]
+	if ok && now < record.FundingTime+int64(intervalHour*3600.0*1000.0) {
+		return record, true
+	}
+
+	if m.settled.pending == nil {
+		m.settled.pending = make(map[string]settledRequest)
+	}
+	m.settled.pending[key] = settledRequest{exchange: exchange, symbol: symbol}
+	return FundingRecord{}, false
+}
+
+// loadPendingSettled 在后台查询待查询的上一期费率，同一时间只有一个查询在进行
+func (m *Monitor) loadPendingSettled() {
+	m.settled.mu.Lock()
+	defer m.settled.mu.Unlock()
+
+	if m.settled.loading || len(m.settled.pending) == 0 {
+		return
+	}
+
+	requests := make([]settledRequest, 0, len(m.settled.pending))
+	for _, request := range m.settled.pending {
+		requests = append(requests, request)
+	}
+	m.settled.pending = nil
+	m.settled.loading = true
+
+	go func() {
+		for _, request := range requests {
+			if record, ok := m.fetchLastSettled(request.exchange, request.symbol); ok {
+				m.settled.mu.Lock()
+				if m.settled.records == nil {
+					m.settled.records = make(map[string]FundingRecord)
+				}
+				m.settled.records[request.exchange+"_"+request.symbol] = record
+				m.settled.mu.Unlock()
+			}
+		}
+
+		m.settled.mu.Lock()
+		m.settled.loading = false
+		m.settled.mu.Unlock()
+	}()
+}
+
+// fetchLastSettled 从历史资金费率接口查询最近一次已结算的费率
+func (m *Monitor) fetchLastSettled(exchange, symbol string) (FundingRecord, bool) {
+	ex := m.getExchange(exchange)
+	if ex == nil {
+		return FundingRecord{}, false
+	}
+
+	now := m.clock.NowMs()
+	records, err := ex.FetchFundingHistory(symbol, now-prevRateLookback.Milliseconds(), now)
+	if err != nil {
+		log.Printf("%s 查询 %s 上一期费率失败: %v", exchange, symbol, err)
+		return FundingRecord{}, false
+	}
+	if len(records) == 0 {
+		return FundingRecord{}, false
+	}
+
+	// 各交易所返回的顺序不同，取结算时间最晚的一条
+	record := records[0]
+	for _, item := range records[1:] {
+		if item.FundingTime > record.FundingTime {
+			record = item
+		}
+	}
+
+	return record, true
+}
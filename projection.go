@@ -0,0 +1,338 @@
+package main
+
+import (
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// 资金费率预测模型，用于下次结算之后的每次结算；下次结算始终使用当前费率
+const (
+	ProjectionConstant = "constant" // 沿用当前费率
+	ProjectionDecay    = "decay"    // 按半衰期指数衰减回归历史平均费率
+	ProjectionPremium  = "premium"  // 按当前溢价（标记价格相对指数价格）估算
+)
+
+// 溢价模型的利率和截断范围，按8小时周期计，与交易所公布的资金费率公式一致
+const (
+	premiumInterestRate = 0.0001 // 利率 0.01%
+	premiumClampRange   = 0.0005 // 利率与溢价之差截断在 ±0.05%
+)
+
+// settledObserveWindow 交易所未提供上一期费率时，结算前这段时间内获取到的费率才作为该期的结算费率记录
+const settledObserveWindow = 5 * time.Minute
+
+// projectedSettlement 预计的一次资金费率结算
+type projectedSettlement struct {
+	Time int64   // 结算时间戳（毫秒）
+	Rate float64 // 预计费率
+}
+
+// ProjectionModel 预测下次结算之后某次结算的资金费率，返回值未按上下限截断
+// intervalHour为预测使用的结算周期，elapsedHour为该次结算距下次结算的小时数
+type ProjectionModel interface {
+	Project(exchange string, contract *ContractData, intervalHour, elapsedHour float64) float64
+}
+
+// newProjectionModel 按配置创建预测模型
+func newProjectionModel(config *Config) ProjectionModel {
+	switch config.ProjectionModel {
+	case ProjectionDecay:
+		return &decayProjection{
+			halfLifeHour: config.ProjectionHalfLifeHour,
+			means: &historyMeans{
+				store:    NewHistoryStore(config.HistoryDir),
+				days:     config.ProjectionMeanDays,
+				entries:  make(map[historyKey]historyMean),
+				pending:  make(map[historyKey]bool),
+				observed: make(map[historyKey]observedRate),
+			},
+		}
+	case ProjectionPremium:
+		return premiumProjection{}
+	}
+	return constantProjection{}
+}
+
+// historyProjection 使用本地历史资金费率的预测模型。Project只读取内存中的缓存，
+// 历史文件在每轮检查之外加载，监控中检测到的每次结算追加到本地历史
+type historyProjection interface {
+	// RefreshHistory 重新加载用到过的交易所+币种，随每小时的结算周期更新进行
+	RefreshHistory()
+	// LoadPendingHistory 在后台加载新用到、尚未加载的交易所+币种
+	LoadPendingHistory()
+	// PreloadHistory 立即加载某交易所+币种（如 -explain）
+	PreloadHistory(exchange, symbol string)
+	// RecordSettlements 把刚结算的费率追加到本地历史
+	RecordSettlements(exchange string, contracts map[string]*ContractData)
+}
+
+// constantProjection 每次结算沿用当前费率
+type constantProjection struct{}
+
+func (constantProjection) Project(exchange string, contract *ContractData, intervalHour, elapsedHour float64) float64 {
+	return contract.FundingRate
+}
+
+// decayProjection 费率与历史平均费率的差距按半衰期指数衰减
+type decayProjection struct {
+	halfLifeHour float64
+	means        *historyMeans
+}
+
+func (d *decayProjection) Project(exchange string, contract *ContractData, intervalHour, elapsedHour float64) float64 {
+	// 没有本地历史数据时回归到利率
+	mean := premiumInterestRate * intervalHour / 8
+	if hourly, ok := d.means.hourly(exchange, contract.Symbol); ok {
+		mean = hourly * intervalHour
+	}
+
+	if d.halfLifeHour <= 0 {
+		return mean
+	}
+	return mean + (contract.FundingRate-mean)*math.Pow(0.5, elapsedHour/d.halfLifeHour)
+}
+
+func (d *decayProjection) RefreshHistory() {
+	d.means.refresh()
+}
+
+func (d *decayProjection) LoadPendingHistory() {
+	d.means.loadPending()
+}
+
+func (d *decayProjection) PreloadHistory(exchange, symbol string) {
+	d.means.load([]historyKey{{exchange, symbol}})
+}
+
+func (d *decayProjection) RecordSettlements(exchange string, contracts map[string]*ContractData) {
+	d.means.observe(exchange, contracts)
+}
+
+// premiumProjection 按当前溢价估算：溢价 + clamp(利率 - 溢价, ±0.05%)，按结算周期折算
+// 缺少标记价格或指数价格时沿用当前费率
+type premiumProjection struct{}
+
+func (premiumProjection) Project(exchange string, contract *ContractData, intervalHour, elapsedHour float64) float64 {
+	if contract.MarkPrice <= 0 || contract.IndexPrice <= 0 {
+		return contract.FundingRate
+	}
+
+	premium := contract.Basis()
+	rate := premium + math.Max(-premiumClampRange, math.Min(premiumClampRange, premiumInterestRate-premium))
+	return rate * intervalHour / 8
+}
+
+// historyKey 交易所+币种
+type historyKey struct {
+	exchange string
+	symbol   string
+}
+
+// historyMean 某交易所某币种的历史平均费率
+type historyMean struct {
+	hourly float64 // 每小时平均费率
+	ok     bool    // 是否有足够的历史数据
+}
+
+// observedRate 最近一次获取到的下次结算时间和费率
+type observedRate struct {
+	nextFundingTime int64
+	rate            float64
+	observedAt      int64 // 获取时间戳（毫秒）
+	prevFundingTime int64 // 已记录的交易所提供的上一期结算时间戳（毫秒）
+}
+
+// historyMeans 从本地历史资金费率计算平均费率，按交易所+币种缓存
+type historyMeans struct {
+	store    *HistoryStore
+	days     float64 // 计算平均值的天数
+	entries  map[historyKey]historyMean
+	pending  map[historyKey]bool // 用到但尚未加载的交易所+币种
+	loading  bool                // 是否正在后台加载pending
+	observed map[historyKey]observedRate
+	mu       sync.Mutex
+}
+
+// hourly 最近days天的每小时平均费率，只读取缓存；尚未加载时记为待加载，ok为false
+func (h *historyMeans) hourly(exchange, symbol string) (float64, bool) {
+	key := historyKey{exchange, symbol}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	entry, ok := h.entries[key]
+	if !ok {
+		h.pending[key] = true
+	}
+	return entry.hourly, entry.ok
+}
+
+// load 读取本地历史并计算平均费率，读取文件时不持有锁
+// 没有历史数据的交易所+币种会回归到利率，汇总输出一条警告
+func (h *historyMeans) load(keys []historyKey) {
+	if len(keys) == 0 {
+		return
+	}
+
+	end := localNow().UnixMilli()
+	start := end - int64(h.days*24*3600*1000)
+
+	loaded := make(map[historyKey]historyMean, len(keys))
+	missing := 0
+	for _, key := range keys {
+		var entry historyMean
+		records, err := h.store.Load(key.exchange, key.symbol)
+		if err != nil {
+			log.Printf("%s 读取 %s 历史资金费率失败: %v", key.exchange, key.symbol, err)
+		} else {
+			entry.hourly, entry.ok = hourlyMeanRate(filterFundingRecords(records, start, end))
+		}
+		if !entry.ok {
+			missing++
+		}
+		loaded[key] = entry
+	}
+
+	h.mu.Lock()
+	for key, entry := range loaded {
+		h.entries[key] = entry
+		delete(h.pending, key)
+	}
+	h.mu.Unlock()
+
+	if missing > 0 {
+		log.Printf("⚠️  decay预测模型: %d/%d 个交易所币种在 %s 中没有最近 %g 天的历史资金费率，回归到利率（每8小时%.2f%%），可先运行 -backfill 回补，监控中也会记录每次结算",
+			missing, len(keys), h.store.dir, h.days, premiumInterestRate*100)
+	}
+}
+
+// refresh 重新加载用到过的所有交易所+币种
+func (h *historyMeans) refresh() {
+	h.mu.Lock()
+	keys := make([]historyKey, 0, len(h.entries)+len(h.pending))
+	for key := range h.entries {
+		keys = append(keys, key)
+	}
+	for key := range h.pending {
+		if _, ok := h.entries[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	h.mu.Unlock()
+
+	h.load(keys)
+}
+
+// loadPending 在后台加载新用到的交易所+币种，同一时间只有一个加载在进行
+func (h *historyMeans) loadPending() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.loading || len(h.pending) == 0 {
+		return
+	}
+
+	keys := make([]historyKey, 0, len(h.pending))
+	for key := range h.pending {
+		keys = append(keys, key)
+	}
+	h.loading = true
+
+	go func() {
+		h.load(keys)
+
+		h.mu.Lock()
+		h.loading = false
+		h.mu.Unlock()
+	}()
+}
+
+// observe 检测每个合约的结算，把刚结算的费率在后台追加到本地历史
+// 交易所提供上一期费率时记录其变化（启动后第一次获取只作为基准）；否则下次结算时间前进时，记录结算前 settledObserveWindow 内最后获取到的费率
+func (h *historyMeans) observe(exchange string, contracts map[string]*ContractData) {
+	now := localNow().UnixMilli()
+	var settled []FundingRecord
+
+	h.mu.Lock()
+	for symbol, contract := range contracts {
+		if contract.NextFundingTime <= 0 {
+			continue
+		}
+
+		key := historyKey{exchange, symbol}
+		last, ok := h.observed[key]
+		h.observed[key] = observedRate{nextFundingTime: contract.NextFundingTime, rate: contract.FundingRate, observedAt: now, prevFundingTime: contract.PrevFundingTime}
+
+		switch {
+		case contract.PrevFundingTime > 0:
+			if ok && contract.PrevFundingTime > last.prevFundingTime {
+				settled = append(settled, FundingRecord{Exchange: exchange, Symbol: symbol, FundingTime: contract.PrevFundingTime, FundingRate: contract.PrevFundingRate})
+			}
+		case ok && last.nextFundingTime < contract.NextFundingTime && last.nextFundingTime <= now &&
+			last.nextFundingTime-last.observedAt <= settledObserveWindow.Milliseconds():
+			settled = append(settled, FundingRecord{Exchange: exchange, Symbol: symbol, FundingTime: last.nextFundingTime, FundingRate: last.rate})
+		}
+	}
+	h.mu.Unlock()
+
+	if len(settled) == 0 {
+		return
+	}
+
+	go func() {
+		for _, record := range settled {
+			if _, err := h.store.Save(record.Exchange, record.Symbol, []FundingRecord{record}); err != nil {
+				log.Printf("%s 保存 %s 结算费率失败: %v", record.Exchange, record.Symbol, err)
+			}
+		}
+	}()
+}
+
+// hourlyMeanRate 按相邻两次结算的间隔折算的每小时平均费率，结算周期变化过也可比较
+func hourlyMeanRate(records []FundingRecord) (float64, bool) {
+	total, hours := 0.0, 0.0
+	for i := 1; i < len(records); i++ {
+		gap := float64(records[i].FundingTime-records[i-1].FundingTime) / (3600.0 * 1000.0)
+		if gap <= 0 {
+			continue
+		}
+		total += records[i].FundingRate
+		hours += gap
+	}
+
+	if hours <= 0 {
+		return 0, false
+	}
+	return total / hours, true
+}
+
+// projectSettlements 预测从下次结算到until（含）之间的每次结算
+// 费率按交易所上下限截断；配置了 CAPPED_INTERVAL_HOUR 时，费率已触及上下限的合约
+// 下次结算之后按缩短后的周期计算（只有少数交易所会在封顶时缩短周期，默认不调整）。
+// 下次结算使用当前费率，之后的结算按配置的预测模型（PROJECTION_MODEL）计算
+func (m *Monitor) projectSettlements(exchange string, contract *ContractData, until int64) []projectedSettlement {
+	if contract.NextFundingTime <= 0 || contract.NextFundingTime > until {
+		return nil
+	}
+
+	intervalHour := contract.FundingIntervalHour
+	if contract.AtFundingCap() && m.config.CappedIntervalHour > 0 && m.config.CappedIntervalHour < intervalHour {
+		intervalHour = m.config.CappedIntervalHour
+	}
+
+	intervalMs := int64(intervalHour * 3600.0 * 1000.0)
+	if intervalMs <= 0 {
+		return nil
+	}
+
+	settlements := []projectedSettlement{{Time: contract.NextFundingTime, Rate: contract.ClampedFundingRate()}}
+	for t := contract.NextFundingTime + intervalMs; t <= until; t += intervalMs {
+		elapsedHour := float64(t-contract.NextFundingTime) / (3600.0 * 1000.0)
+		rate := m.projection.Project(exchange, contract, intervalHour, elapsedHour)
+		settlements = append(settlements, projectedSettlement{Time: t, Rate: contract.ClampRate(rate)})
+	}
+
+	return settlements
+}
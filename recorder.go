@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recordFileSuffix 录制文件后缀，每个交易所一个文件：<目录>/<交易所>.jsonl.gz
+const recordFileSuffix = ".jsonl.gz"
+
+// recordMaxLineSize 单条录制记录的最大长度，Gate等交易所的全量行情响应超过1MB
+const recordMaxLineSize = 64 * 1024 * 1024
+
+// RecordedRequest 一次请求及其响应
+type RecordedRequest struct {
+	Time        time.Time   `json:"time"`        // 发出请求的本地时间
+	DurationMs  int64       `json:"duration_ms"` // 请求耗时（毫秒）
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	RequestBody []byte      `json:"request_body,omitempty"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+	Error       string      `json:"error,omitempty"` // 请求失败时的错误信息，此时没有响应
+}
+
+// doneAt 收到响应的本地时间
+func (r RecordedRequest) doneAt() time.Time {
+	return r.Time.Add(time.Duration(r.DurationMs) * time.Millisecond)
+}
+
+// requestKey 回放时匹配请求的标识
+func requestKey(method, url string) string {
+	return method + " " + url
+}
+
+// Recorder 把各交易所的请求和响应写入gzip压缩的JSONL文件
+type Recorder struct {
+	dir   string
+	files map[string]*recordFile
+	mu    sync.Mutex
+}
+
+type recordFile struct {
+	file *os.File
+	gz   *gzip.Writer
+	mu   sync.Mutex
+}
+
+// NewRecorder 在baseDir下按启动时间创建本次的录制目录
+func NewRecorder(baseDir string) (*Recorder, error) {
+	dir := filepath.Join(baseDir, time.Now().Format("20060102-150405"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建录制目录失败: %v", err)
+	}
+
+	return &Recorder{
+		dir:   dir,
+		files: make(map[string]*recordFile),
+	}, nil
+}
+
+// Dir 本次录制的目录，回放时传入该目录
+func (r *Recorder) Dir() string {
+	return r.dir
+}
+
+// Transport 返回记录该交易所所有请求的RoundTripper
+func (r *Recorder) Transport(exchange string, base http.RoundTripper) (http.RoundTripper, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out, ok := r.files[exchange]
+	if !ok {
+		file, err := os.OpenFile(filepath.Join(r.dir, exchange+recordFileSuffix), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("创建录制文件失败: %v", err)
+		}
+		out = &recordFile{file: file, gz: gzip.NewWriter(file)}
+		r.files[exchange] = out
+	}
+
+	return &recordTransport{exchange: exchange, base: base, out: out}, nil
+}
+
+// write 写入一条记录，每条都刷新到文件，进程被直接终止时已写入的记录仍可读取
+func (f *recordFile) write(record RecordedRequest) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.gz.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return f.gz.Flush()
+}
+
+// recordTransport 转发请求并记录请求和完整响应
+type recordTransport struct {
+	exchange string
+	base     http.RoundTripper
+	out      *recordFile
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	record := RecordedRequest{
+		Time:   time.Now(),
+		Method: req.Method,
+		URL:    req.URL.String(),
+	}
+
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			record.RequestBody, _ = io.ReadAll(body)
+			body.Close()
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		record.DurationMs = time.Since(record.Time).Milliseconds()
+		record.Error = err.Error()
+		t.save(record)
+		return nil, err
+	}
+
+	// 读出完整响应后再交给调用方
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	record.DurationMs = time.Since(record.Time).Milliseconds()
+	if err != nil {
+		record.Error = err.Error()
+		t.save(record)
+		return nil, err
+	}
+
+	record.Status = resp.StatusCode
+	record.Header = resp.Header
+	record.Body = body
+	t.save(record)
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	return resp, nil
+}
+
+func (t *recordTransport) save(record RecordedRequest) {
+	if err := t.out.write(record); err != nil {
+		log.Printf("%s 写入录制数据失败: %v", t.exchange, err)
+	}
+}
+
+// Replay 按录制顺序返回各交易所的响应
+// 同一请求（方法+URL）按录制的先后依次返回，不同请求之间互不影响，并发请求的先后顺序不影响结果
+type Replay struct {
+	queues  map[string]map[string][]RecordedRequest // exchange -> 请求标识 -> 未回放的记录
+	now     time.Time                               // 已回放记录中最晚的响应时间
+	pending int                                     // 未回放的记录数
+	missed  int                                     // 录制数据中找不到的请求数
+	mu      sync.Mutex
+}
+
+// LoadReplay 读取录制目录下所有交易所的记录
+func LoadReplay(dir string) (*Replay, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+recordFileSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("读取录制目录失败: %v", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("录制目录 %s 中没有录制文件", dir)
+	}
+
+	replay := &Replay{
+		queues: make(map[string]map[string][]RecordedRequest),
+	}
+
+	for _, path := range paths {
+		exchange := strings.TrimSuffix(filepath.Base(path), recordFileSuffix)
+		records, err := readRecordFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取 %s 失败: %v", path, err)
+		}
+
+		queues := make(map[string][]RecordedRequest)
+		for _, record := range records {
+			key := requestKey(record.Method, record.URL)
+			queues[key] = append(queues[key], record)
+
+			// 回放时钟从最早的请求时间开始
+			if replay.now.IsZero() || record.Time.Before(replay.now) {
+				replay.now = record.Time
+			}
+		}
+		replay.queues[exchange] = queues
+		replay.pending += len(records)
+
+		log.Printf("%s 载入 %d 条录制记录", exchange, len(records))
+	}
+
+	return replay, nil
+}
+
+// readRecordFile 读取一个录制文件，进程被直接终止导致的文件尾缺失或最后一行不完整时忽略该部分
+func readRecordFile(path string) ([]RecordedRequest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 0, 1024*1024), recordMaxLineSize)
+
+	var records []RecordedRequest
+	truncated := false
+	for scanner.Scan() {
+		if truncated {
+			return nil, fmt.Errorf("第 %d 条记录格式错误", len(records)+1)
+		}
+
+		var record RecordedRequest
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			truncated = true
+			continue
+		}
+		records = append(records, record)
+	}
+
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	if truncated {
+		log.Printf("%s 最后一条记录不完整，已忽略", path)
+	}
+
+	return records, nil
+}
+
+// Transport 返回回放该交易所录制数据的RoundTripper
+func (r *Replay) Transport(exchange string) http.RoundTripper {
+	return &replayTransport{exchange: exchange, replay: r}
+}
+
+// Now 回放时钟：已回放记录中最晚的响应时间
+func (r *Replay) Now() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.now
+}
+
+// Pending 未回放的记录数
+func (r *Replay) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.pending
+}
+
+// Missed 录制数据中找不到的请求数
+func (r *Replay) Missed() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.missed
+}
+
+// next 取出该请求的下一条记录，并推进回放时钟
+func (r *Replay) next(exchange, key string) (RecordedRequest, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	queue := r.queues[exchange][key]
+	if len(queue) == 0 {
+		r.missed++
+		return RecordedRequest{}, false
+	}
+
+	record := queue[0]
+	r.queues[exchange][key] = queue[1:]
+	r.pending--
+	if doneAt := record.doneAt(); doneAt.After(r.now) {
+		r.now = doneAt
+	}
+
+	return record, true
+}
+
+// replayTransport 不访问网络，返回录制的响应
+type replayTransport struct {
+	exchange string
+	replay   *Replay
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	key := requestKey(req.Method, req.URL.String())
+	record, ok := t.replay.next(t.exchange, key)
+	if !ok {
+		return nil, fmt.Errorf("录制数据中没有该请求: %s", key)
+	}
+
+	if record.Error != "" {
+		return nil, errors.New(record.Error)
+	}
+
+	header := record.Header
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", record.Status, http.StatusText(record.Status)),
+		StatusCode:    record.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(record.Body)),
+		ContentLength: int64(len(record.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+)
+
+// 字段检查的数据来源
+const (
+	SchemaEndpointFunding  = "资金费率" // 每轮获取的费率和价格
+	SchemaEndpointInterval = "结算周期" // 每小时更新的结算周期和合约信息
+)
+
+// schemaDriftFetches 资金费率连续多少次获取超过阈值才告警，避免结算瞬间等短暂异常误报
+const schemaDriftFetches = 3
+
+// schemaStaleGraceMs 下次结算时间早于当前时间超过该值才视为不合理，结算前后交易所会短暂返回刚过去的结算时间
+const schemaStaleGraceMs = 60 * 1000
+
+// SchemaCheck 一次获取中各必需字段的检查结果
+type SchemaCheck struct {
+	Exchange  string
+	Endpoint  string         // SchemaEndpointFunding / SchemaEndpointInterval
+	Items     int            // 检查的条目数
+	Checked   map[string]int // 字段 -> 检查的条目数
+	Defaulted map[string]int // 字段 -> 缺失或不合理、使用了默认值的条目数
+}
+
+// Item 开始检查一条数据
+func (c *SchemaCheck) Item() {
+	c.Items++
+}
+
+// Require 记录一个必需字段是否存在且合理
+func (c *SchemaCheck) Require(field string, ok bool) {
+	c.Checked[field]++
+	if !ok {
+		c.Defaulted[field]++
+	}
+}
+
+// Fraction 字段缺失或不合理的比例
+func (c *SchemaCheck) Fraction(field string) float64 {
+	if c.Checked[field] == 0 {
+		return 0
+	}
+	return float64(c.Defaulted[field]) / float64(c.Checked[field])
+}
+
+// Fields 按名称排序的已检查字段
+func (c *SchemaCheck) Fields() []string {
+	fields := make([]string, 0, len(c.Checked))
+	for field := range c.Checked {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// validNextFundingTime 下次结算时间是否存在且不早于当前时间
+func validNextFundingTime(nextFundingTime int64) bool {
+	return nextFundingTime > localNow().UnixMilli()-schemaStaleGraceMs
+}
+
+// SchemaTracker 收集交易所每次获取的字段检查结果
+type SchemaTracker struct {
+	exchange string
+	pending  []SchemaCheck
+	mu       sync.Mutex
+}
+
+func NewSchemaTracker(exchange string) *SchemaTracker {
+	return &SchemaTracker{exchange: exchange}
+}
+
+// Begin 开始一次获取的检查
+func (t *SchemaTracker) Begin(endpoint string) *SchemaCheck {
+	return &SchemaCheck{
+		Exchange:  t.exchange,
+		Endpoint:  endpoint,
+		Checked:   make(map[string]int),
+		Defaulted: make(map[string]int),
+	}
+}
+
+// Record 保存检查结果，等待监控取出
+func (t *SchemaTracker) Record(check *SchemaCheck) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pending = append(t.pending, *check)
+}
+
+// Drain 取出尚未处理的检查结果
+func (t *SchemaTracker) Drain() []SchemaCheck {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	checks := t.pending
+	t.pending = nil
+	return checks
+}
+
+// schemaState 某个字段的告警状态
+type schemaState struct {
+	streak  int  // 连续超过阈值的次数
+	alerted bool // 是否已告警，恢复后发送恢复通知
+}
+
+// handleSchemaChecks 检查各交易所的字段缺失比例，连续多次超过阈值时告警接口格式可能已变化，恢复后再通知一次
+func (m *Monitor) handleSchemaChecks() {
+	var lines []string
+
+	m.mu.Lock()
+	for _, exchange := range m.exchanges {
+		for _, check := range exchange.SchemaChecks() {
+			// 整个接口返回空列表视为字段全部缺失
+			if check.Items == 0 {
+				check.Checked["(全部数据)"] = 1
+				check.Defaulted["(全部数据)"] = 1
+			}
+
+			// 结算周期每小时才获取一次，超过阈值即告警
+			required := schemaDriftFetches
+			if check.Endpoint == SchemaEndpointInterval {
+				required = 1
+			}
+
+			for _, field := range check.Fields() {
+				key := check.Exchange + "_" + check.Endpoint + "_" + field
+				state, ok := m.schemaStates[key]
+				if !ok {
+					state = &schemaState{}
+					m.schemaStates[key] = state
+				}
+
+				fraction := check.Fraction(field)
+				if fraction > m.config.SchemaDriftThreshold {
+					state.streak++
+					if state.streak >= required && !state.alerted {
+						state.alerted = true
+						lines = append(lines, fmt.Sprintf("⚠️ %s %s: %s 缺失或异常 %.1f%% (%d/%d)，已使用默认值",
+							check.Exchange, check.Endpoint, field, fraction*100, check.Defaulted[field], check.Checked[field]))
+					}
+					continue
+				}
+
+				if state.alerted {
+					lines = append(lines, fmt.Sprintf("✅ %s %s: %s 已恢复，缺失比例 %.1f%%",
+						check.Exchange, check.Endpoint, field, fraction*100))
+				}
+				state.streak = 0
+				state.alerted = false
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	if len(lines) == 0 {
+		return
+	}
+
+	message := "🛠 交易所接口格式可能已变化\n\n"
+	for _, line := range lines {
+		log.Printf("接口字段检查: %s", line)
+		message += line + "\n"
+	}
+	message += fmt.Sprintf("\n字段缺失比例超过 %.0f%% 时告警（资金费率需连续 %d 次）\n", m.config.SchemaDriftThreshold*100, schemaDriftFetches)
+
+	if m.notify == nil {
+		return
+	}
+
+	if err := m.notify(message); err != nil {
+		log.Printf("发送接口格式变化通知失败: %v", err)
+	}
+}
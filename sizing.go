@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// 套利机会的排序方式
+const (
+	RankByPercent = "percent" // 按净收益率
+	RankByUSDT    = "usdt"    // 按建议仓位的净收益（USDT）
+	RankByAPR     = "apr"     // 按年化收益率
+	RankByMargin  = "margin"  // 按保证金收益率
+)
+
+// hoursPerYear 年化收益率按每年的小时数折算
+const hoursPerYear = 365 * 24
+
+// minAPRHoldingHours 年化收益率的最短持仓时长（小时），临近结算时持仓时长趋近0，不设下限时年化会无限放大
+const minAPRHoldingHours = 1.0
+
+// budgetNotional 单笔预算和两边交易所的资金×杠杆允许的每条腿名义价值（USDT），同时返回限制因素
+func (m *Monitor) budgetNotional(highExchange, lowExchange string) (float64, string) {
+	sizing := &m.config.Sizing
+	notional, limit := sizing.TradeNotional, "单笔预算"
+
+	for _, exchange := range []string{highExchange, lowExchange} {
+		capital := sizing.CapitalFor(exchange)
+		if capital <= 0 {
+			continue
+		}
+		if value := capital * sizing.LeverageFor(exchange); value < notional {
+			notional, limit = value, fmt.Sprintf("%s资金×%.0f倍", exchange, sizing.LeverageFor(exchange))
+		}
+	}
+
+	return notional, limit
+}
+
+// applySizing 计算建议仓位和按USDT计的收益
+// 已检查盘口时建议仓位不超过净收益仍高于阈值的最大名义价值，价差按该仓位的成交均价计算；否则按盘口一档的价差计算
+func (m *Monitor) applySizing(opp *ArbitrageOpportunity, lowAsks, highBids []OrderBookLevel) {
+	notional, limit := m.budgetNotional(opp.HighRateExchange, opp.LowRateExchange)
+	spread := opp.PriceSpread
+
+	if opp.DepthChecked {
+		if opp.MaxNotional < notional {
+			notional, limit = opp.MaxNotional, "盘口深度"
+		}
+		if fill, ok := fillSpread(lowAsks, highBids, notional); ok {
+			spread = fill
+		}
+	}
+
+	opp.RecommendedNotional = notional
+	opp.NotionalLimit = limit
+	opp.FundingIncomeUSDT = notional * opp.FundingEdge
+	opp.SpreadCostUSDT = notional * spread
+	opp.FeeUSDT = notional * opp.FeeCost
+	opp.NetProfitUSDT = opp.FundingIncomeUSDT - opp.SpreadCostUSDT - opp.FeeUSDT
+
+	// 两条腿各占用 名义价值/杠杆 的保证金
+	opp.HighLeverage = m.config.Sizing.LeverageFor(opp.HighRateExchange)
+	opp.LowLeverage = m.config.Sizing.LeverageFor(opp.LowRateExchange)
+	marginPerNotional := 1/opp.HighLeverage + 1/opp.LowLeverage
+	opp.MarginUSDT = notional * marginPerNotional
+
+	// 保证金收益率和年化按建议仓位的净收益计算（已检查盘口时为成交均价的价差），仓位为0时按盘口一档的净收益率
+	netRate := opp.NetProfit
+	if notional > 0 {
+		netRate = opp.NetProfitUSDT / notional
+	}
+	opp.ReturnOnMargin = netRate / marginPerNotional
+
+	// 持仓期间的收益按年折算，保证金只在开仓后占用
+	opp.APR = netRate * hoursPerYear / aprHoldingHours(opp)
+}
+
+// aprHoldingHours 年化收益率使用的持仓时长：不短于较短一边的结算周期，且不短于 minAPRHoldingHours
+// 同一组合一个结算周期内最多套利一次，按更短的持仓时长折算会高估年化
+func aprHoldingHours(opp *ArbitrageOpportunity) float64 {
+	hours := math.Max(opp.HoldingHours, minAPRHoldingHours)
+	interval := opp.HighRateIntervalH
+	if opp.LowRateIntervalH > 0 && (interval <= 0 || opp.LowRateIntervalH < interval) {
+		interval = opp.LowRateIntervalH
+	}
+	return math.Max(hours, interval)
+}
+
+// betterOpportunity 按配置的排序方式，a是否优于b
+func (m *Monitor) betterOpportunity(a, b *ArbitrageOpportunity) bool {
+	switch m.config.RankBy {
+	case RankByUSDT:
+		return a.NetProfitUSDT > b.NetProfitUSDT
+	case RankByAPR:
+		return a.APR > b.APR
+	case RankByMargin:
+		return a.ReturnOnMargin > b.ReturnOnMargin
+	}
+	return a.NetProfit > b.NetProfit
+}
+
+// sortOpportunities 按配置的方式排序，排在前面的优先通知
+func (m *Monitor) sortOpportunities(opportunities []ArbitrageOpportunity) {
+	sort.SliceStable(opportunities, func(i, j int) bool {
+		return m.betterOpportunity(&opportunities[i], &opportunities[j])
+	})
+}
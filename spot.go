@@ -0,0 +1,301 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 期现套利方向
+const (
+	SpotCarryLongSpot  = "long_spot"  // 买入现货 + 做空正费率永续合约
+	SpotCarryShortSpot = "short_spot" // 借币卖出现货 + 做多负费率永续合约
+)
+
+// SpotCarryOpportunity 期现套利机会
+type SpotCarryOpportunity struct {
+	Symbol           string
+	Direction        string  // SpotCarryLongSpot / SpotCarryShortSpot
+	PerpExchange     string  // 永续合约腿的交易所
+	SpotExchange     string  // 现货腿的交易所
+	FundingRate      float64 // 原始费率
+	IntervalH        float64 // 结算周期（小时）
+	RatePredicted    bool
+	Settlements      int     // 持有期内的结算次数
+	AccumulatedRate  float64 // 持有期内的累计费率
+	PerpPrice        float64 // 永续合约成交价：做空用买一，做多用卖一
+	SpotPrice        float64 // 现货成交价：买入用卖一，卖出用买一
+	PriceSpread      float64 // 可成交价差比：买入腿价格相对卖出腿价格
+	BorrowHourlyRate float64 // 做空现货的借币小时利率
+	BorrowCost       float64 // 持有期内的借币成本
+	PerpFeeRate      float64 // 合约每次成交的手续费率
+	SpotFeeRate      float64 // 现货每次成交的手续费率
+	FeeCost          float64 // 合约和现货开仓、平仓共4次成交的手续费，NetProfit已扣除
+	NetProfit        float64
+	Threshold        float64
+	HorizonHour      float64 // 持有期（小时）
+	Timestamp        time.Time
+}
+
+// fetchSpotTickers 并发获取所有支持现货行情的交易所数据，exchange -> symbol -> ticker
+func (m *Monitor) fetchSpotTickers() map[string]map[string]*SpotTicker {
+	result := make(map[string]map[string]*SpotTicker)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, exchange := range m.exchanges {
+		spot, ok := exchange.(SpotMarket)
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func(name string, spot SpotMarket) {
+			defer wg.Done()
+			tickers, err := spot.FetchSpotTickers()
+			if err != nil {
+				log.Printf("%s 获取现货行情失败: %v", name, err)
+				return
+			}
+
+			// 现货与合约使用同一成交额下限
+			minVolume := m.config.Liquidity.MinVolumeFor(name)
+			for symbol, ticker := range tickers {
+				if ticker.QuoteVolume24h < minVolume {
+					delete(tickers, symbol)
+				}
+			}
+
+			mu.Lock()
+			result[name] = tickers
+			mu.Unlock()
+		}(exchange.Name(), spot)
+	}
+
+	wg.Wait()
+	return result
+}
+
+// analyzeSpotPerp 对每个永续合约计算持有期内的期现套利收益
+// 正费率：在净收益最高的交易所买入现货，做空合约；
+// 负费率：在可借币的交易所借币卖出现货，做多合约，扣除持有期内的借币成本
+// 两种方向都扣除合约和现货开仓、平仓共4次成交的手续费，与合约间套利一致；两条腿都只在 TRADE_EXCHANGES 中选择
+func (m *Monitor) analyzeSpotPerp(perpData map[string]map[string]*ContractData, spotData map[string]map[string]*SpotTicker, borrowData map[string]map[string]*BorrowRate) []SpotCarryOpportunity {
+	var opportunities []SpotCarryOpportunity
+
+	horizon := m.config.SpotCarryHorizonHour
+	until := m.clock.NowMs() + int64(horizon*3600.0*1000.0)
+	threshold := m.getThreshold()
+
+	for perpExchange, contracts := range perpData {
+		if !m.config.CanTrade(perpExchange) {
+			continue
+		}
+		perpFee, _ := m.tradingFee(perpExchange)
+
+		for symbol, contract := range contracts {
+			settlements := m.projectSettlements(perpExchange, contract, until)
+			accumulatedRate := 0.0
+			for _, settlement := range settlements {
+				accumulatedRate += settlement.Rate
+			}
+
+			var opp *SpotCarryOpportunity
+			switch {
+			case accumulatedRate > 0:
+				opp = m.longSpotCarry(symbol, contract, accumulatedRate, perpFee, spotData)
+			case accumulatedRate < 0:
+				opp = m.shortSpotCarry(symbol, contract, accumulatedRate, perpFee, spotData, borrowData, horizon)
+			}
+			if opp == nil || opp.NetProfit <= threshold {
+				continue
+			}
+
+			opp.PerpExchange = perpExchange
+			opp.FundingRate = contract.FundingRate
+			opp.IntervalH = contract.FundingIntervalHour
+			opp.RatePredicted = contract.FundingRatePredicted
+			opp.Settlements = len(settlements)
+			opp.AccumulatedRate = accumulatedRate
+			opp.Threshold = threshold
+			opp.HorizonHour = horizon
+			opp.Timestamp = localNow()
+			opportunities = append(opportunities, *opp)
+		}
+	}
+
+	sort.Slice(opportunities, func(i, j int) bool {
+		return opportunities[i].NetProfit > opportunities[j].NetProfit
+	})
+
+	return opportunities
+}
+
+// longSpotCarry 买入现货 + 做空合约，现货选扣除价差和手续费后净收益最高的交易所
+func (m *Monitor) longSpotCarry(symbol string, contract *ContractData, accumulatedRate, perpFee float64, spotData map[string]map[string]*SpotTicker) *SpotCarryOpportunity {
+	perpBid := contract.SellPrice(m.config.PriceSource)
+	if perpBid <= 0 {
+		return nil
+	}
+
+	var best *SpotCarryOpportunity
+	for spotExchange, tickers := range spotData {
+		if !m.config.CanTrade(spotExchange) {
+			continue
+		}
+		ticker, ok := tickers[symbol]
+		if !ok || ticker.AskPrice <= 0 {
+			continue
+		}
+
+		spotFee := m.spotTradingFee(spotExchange)
+		priceSpread := (ticker.AskPrice - perpBid) / perpBid
+		feeCost := 2*perpFee + 2*spotFee
+		netProfit := accumulatedRate - priceSpread - feeCost
+		if best != nil && netProfit <= best.NetProfit {
+			continue
+		}
+
+		best = &SpotCarryOpportunity{
+			Symbol:       symbol,
+			Direction:    SpotCarryLongSpot,
+			SpotExchange: spotExchange,
+			PerpPrice:    perpBid,
+			SpotPrice:    ticker.AskPrice,
+			PriceSpread:  priceSpread,
+			PerpFeeRate:  perpFee,
+			SpotFeeRate:  spotFee,
+			FeeCost:      feeCost,
+			NetProfit:    netProfit,
+		}
+	}
+
+	return best
+}
+
+// shortSpotCarry 借币卖出现货 + 做多合约，只考虑能借到该币的交易所，选净收益最高的
+func (m *Monitor) shortSpotCarry(symbol string, contract *ContractData, accumulatedRate, perpFee float64, spotData map[string]map[string]*SpotTicker, borrowData map[string]map[string]*BorrowRate, horizon float64) *SpotCarryOpportunity {
+	perpAsk := contract.BuyPrice(m.config.PriceSource)
+	if perpAsk <= 0 {
+		return nil
+	}
+
+	asset := strings.TrimSuffix(symbol, "USDT")
+
+	var best *SpotCarryOpportunity
+	for spotExchange, tickers := range spotData {
+		if !m.config.CanTrade(spotExchange) {
+			continue
+		}
+		ticker, ok := tickers[symbol]
+		if !ok || ticker.BidPrice <= 0 {
+			continue
+		}
+		borrow, ok := borrowData[spotExchange][asset]
+		if !ok || !borrow.Borrowable {
+			continue
+		}
+
+		spotFee := m.spotTradingFee(spotExchange)
+		priceSpread := (perpAsk - ticker.BidPrice) / ticker.BidPrice
+		borrowCost := borrow.HourlyRate * horizon
+		feeCost := 2*perpFee + 2*spotFee
+		netProfit := -accumulatedRate - priceSpread - borrowCost - feeCost
+		if best != nil && netProfit <= best.NetProfit {
+			continue
+		}
+
+		best = &SpotCarryOpportunity{
+			Symbol:           symbol,
+			Direction:        SpotCarryShortSpot,
+			SpotExchange:     spotExchange,
+			PerpPrice:        perpAsk,
+			SpotPrice:        ticker.BidPrice,
+			PriceSpread:      priceSpread,
+			BorrowHourlyRate: borrow.HourlyRate,
+			BorrowCost:       borrowCost,
+			PerpFeeRate:      perpFee,
+			SpotFeeRate:      spotFee,
+			FeeCost:          feeCost,
+			NetProfit:        netProfit,
+		}
+	}
+
+	return best
+}
+
+// sendSpotCarryNotifications 发送期现套利机会通知，与合约间套利共用1小时内不重复通知的规则
+func (m *Monitor) sendSpotCarryNotifications(opportunities []SpotCarryOpportunity) {
+	if m.notify == nil {
+		log.Println("未配置微信webhook，跳过通知")
+		return
+	}
+
+	now := localNow()
+	var validOpportunities []SpotCarryOpportunity
+
+	m.mu.Lock()
+	for _, opp := range opportunities {
+		key := fmt.Sprintf("spot_%s_%s_%s_%s", opp.Direction, opp.Symbol, opp.PerpExchange, opp.SpotExchange)
+		lastTime, exists := m.lastNotifications[key]
+		if !exists || now.Sub(lastTime) >= 1*time.Hour {
+			validOpportunities = append(validOpportunities, opp)
+			m.lastNotifications[key] = now
+		}
+	}
+	m.mu.Unlock()
+
+	if len(validOpportunities) == 0 {
+		return
+	}
+
+	count := len(validOpportunities)
+	if count > 5 {
+		count = 5
+	}
+
+	message := fmt.Sprintf("🔔 发现 %d 个期现套利机会\n\n", len(validOpportunities))
+
+	for i := 0; i < count; i++ {
+		opp := validOpportunities[i]
+
+		message += fmt.Sprintf("【%s】\n", opp.Symbol)
+		message += fmt.Sprintf("持有期: %.0f小时\n", opp.HorizonHour)
+		message += fmt.Sprintf("净收益: %.4f%% (阈值: %.2f%%)\n", opp.NetProfit*100, opp.Threshold*100)
+		if opp.Direction == SpotCarryShortSpot {
+			message += fmt.Sprintf("做多合约: %s %.4f%%%s × %d次 = %.4f%% (周期 %.0fh)\n",
+				opp.PerpExchange, opp.FundingRate*100, predictedTag(opp.RatePredicted),
+				opp.Settlements, opp.AccumulatedRate*100, opp.IntervalH)
+			message += fmt.Sprintf("借币卖出现货: %s\n", opp.SpotExchange)
+			message += fmt.Sprintf("借币成本: %.4f%%/小时 × %.0f小时 = %.4f%%\n",
+				opp.BorrowHourlyRate*100, opp.HorizonHour, opp.BorrowCost*100)
+			message += fmt.Sprintf("价差比: %.4f%%\n", opp.PriceSpread*100)
+			message += spotFeeText(opp)
+			message += fmt.Sprintf("成交价: 现货卖 %.4f / 合约买 %.4f\n", opp.SpotPrice, opp.PerpPrice)
+		} else {
+			message += fmt.Sprintf("做空合约: %s %.4f%%%s × %d次 = %.4f%% (周期 %.0fh)\n",
+				opp.PerpExchange, opp.FundingRate*100, predictedTag(opp.RatePredicted),
+				opp.Settlements, opp.AccumulatedRate*100, opp.IntervalH)
+			message += fmt.Sprintf("买入现货: %s\n", opp.SpotExchange)
+			message += fmt.Sprintf("价差比: %.4f%%\n", opp.PriceSpread*100)
+			message += spotFeeText(opp)
+			message += fmt.Sprintf("成交价: 合约卖 %.4f / 现货买 %.4f\n", opp.PerpPrice, opp.SpotPrice)
+		}
+		message += "\n"
+	}
+
+	if err := m.notify(message); err != nil {
+		log.Printf("发送微信通知失败: %v", err)
+	} else {
+		log.Printf("已发送微信通知，包含 %d 个期现套利机会", count)
+	}
+}
+
+// spotFeeText 期现套利通知中的手续费，两条腿各开仓、平仓一次
+func spotFeeText(opp SpotCarryOpportunity) string {
+	return fmt.Sprintf("手续费: 合约 %.4f%% × 2 + 现货 %.4f%% × 2 = %.4f%%\n",
+		opp.PerpFeeRate*100, opp.SpotFeeRate*100, opp.FeeCost*100)
+}
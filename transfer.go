@@ -0,0 +1,311 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// transferRefreshInterval 充提状态的缓存时间
+const transferRefreshInterval = 30 * time.Minute
+
+// transferFetchTimeout 等待一次充提状态查询的最长时间，超时后本轮按已有缓存处理，查询完成后写入缓存供之后使用
+const transferFetchTimeout = 5 * time.Second
+
+// networkAliases 各交易所的链名称统一为常用名称
+var networkAliases = map[string]string{
+	"TRX":              "TRC20",
+	"TRON":             "TRC20",
+	"ETH":              "ERC20",
+	"BSC":              "BEP20",
+	"BNB":              "BEP20",
+	"BEP20(BSC)":       "BEP20",
+	"ARBEVM":           "ARBITRUM",
+	"ARBITRUMONE":      "ARBITRUM",
+	"ARB":              "ARBITRUM",
+	"ARBI":             "ARBITRUM",
+	"MATIC":            "POLYGON",
+	"OPETH":            "OPTIMISM",
+	"OP":               "OPTIMISM",
+	"SOL":              "SOLANA",
+	"SPL":              "SOLANA",
+	"AVAX_C":           "AVAXC",
+	"C-CHAIN":          "AVAXC",
+	"AVALANCHEC-CHAIN": "AVAXC",
+	"CAVAX":            "AVAXC",
+	"BITCOIN":          "BTC",
+}
+
+// networkBlockSeconds 各网络的出块时间（秒），用于按确认数估算到账时间
+var networkBlockSeconds = map[string]float64{
+	"TRC20":    3,
+	"ERC20":    12,
+	"BEP20":    3,
+	"ARBITRUM": 0.25,
+	"POLYGON":  2,
+	"OPTIMISM": 2,
+	"SOLANA":   0.4,
+	"AVAXC":    2,
+	"TON":      5,
+	"BTC":      600,
+}
+
+// networkDefaultMinutes 交易所未提供确认数时各网络的典型到账时间（分钟）
+var networkDefaultMinutes = map[string]float64{
+	"TRC20":    3,
+	"ERC20":    5,
+	"BEP20":    3,
+	"ARBITRUM": 3,
+	"POLYGON":  5,
+	"OPTIMISM": 3,
+	"SOLANA":   2,
+	"AVAXC":    2,
+	"TON":      3,
+	"BTC":      30,
+}
+
+// normalizeNetwork 统一链名称，OKX的链名称带币种前缀且可能含空格，如 USDT-Arbitrum One
+func normalizeNetwork(chain string) string {
+	name := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(chain), " ", ""))
+	if alias, ok := networkAliases[name]; ok {
+		return alias
+	}
+	return name
+}
+
+// parseWithdrawFee 解析提币手续费，交易所未返回时为-1（未知）
+func parseWithdrawFee(fee string) float64 {
+	if strings.TrimSpace(fee) == "" {
+		return -1
+	}
+	return parseFloat(fee)
+}
+
+// estimateTransferMinutes 估算到账时间（分钟），0表示未知
+func estimateTransferMinutes(status NetworkStatus) float64 {
+	if blockSeconds, ok := networkBlockSeconds[status.Network]; ok && status.Confirmations > 0 {
+		return math.Max(1, float64(status.Confirmations)*blockSeconds/60)
+	}
+	return networkDefaultMinutes[status.Network]
+}
+
+// TransferRoute 资金转入某交易所的路线
+type TransferRoute struct {
+	Asset            string
+	Network          string
+	FromExchange     string  // 提币的交易所
+	Fee              float64 // 提币手续费，-1表示未知
+	EstimatedMinutes float64 // 预计到账时间，0表示未知
+}
+
+// transferCache 各交易所充提状态的缓存
+type transferCache struct {
+	statuses  map[string][]NetworkStatus // exchange_asset -> 各链状态
+	fetchedAt map[string]time.Time
+	fetching  map[string]chan struct{} // exchange_asset -> 进行中的查询，完成时关闭
+	mu        sync.Mutex
+}
+
+// transferFetcher 交易所查询充提状态的方法：公开接口，或使用默认账户凭证的签名接口；都不可用时返回nil
+func (m *Monitor) transferFetcher(exchange Exchange) func(asset string) ([]NetworkStatus, error) {
+	switch market := exchange.(type) {
+	case TransferMarket:
+		return market.FetchNetworkStatus
+	case AuthTransferMarket:
+		client := m.accounts.Get(DefaultAccount, exchange.Name())
+		if client == nil {
+			return nil
+		}
+		return func(asset string) ([]NetworkStatus, error) {
+			return market.FetchAuthNetworkStatus(client, asset)
+		}
+	}
+	return nil
+}
+
+// refreshNetworkStatus 缓存过期时查询交易所某币种的充提状态。查询在锁外进行，最多等待 transferFetchTimeout，
+// 同一交易所和币种同时只有一个查询
+func (m *Monitor) refreshNetworkStatus(exchange Exchange, asset string) {
+	fetch := m.transferFetcher(exchange)
+	if fetch == nil {
+		return
+	}
+
+	key := exchange.Name() + "_" + asset
+
+	m.transfer.mu.Lock()
+	if m.transfer.statuses == nil {
+		m.transfer.statuses = make(map[string][]NetworkStatus)
+		m.transfer.fetchedAt = make(map[string]time.Time)
+		m.transfer.fetching = make(map[string]chan struct{})
+	}
+	if fetchedAt, ok := m.transfer.fetchedAt[key]; ok && localNow().Sub(fetchedAt) < transferRefreshInterval {
+		m.transfer.mu.Unlock()
+		return
+	}
+	done, ok := m.transfer.fetching[key]
+	if !ok {
+		done = make(chan struct{})
+		m.transfer.fetching[key] = done
+		go m.fetchNetworkStatus(exchange.Name(), asset, fetch, done)
+	}
+	m.transfer.mu.Unlock()
+
+	timer := time.NewTimer(transferFetchTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+		log.Printf("%s 获取 %s 充提状态超时，本轮使用已有数据", exchange.Name(), asset)
+	}
+}
+
+// fetchNetworkStatus 查询充提状态并写入缓存，完成时关闭done
+func (m *Monitor) fetchNetworkStatus(exchangeName, asset string, fetch func(asset string) ([]NetworkStatus, error), done chan struct{}) {
+	key := exchangeName + "_" + asset
+	statuses, err := fetch(asset)
+
+	m.transfer.mu.Lock()
+	defer m.transfer.mu.Unlock()
+
+	if err != nil {
+		log.Printf("%s 获取 %s 充提状态失败: %v", exchangeName, asset, err)
+	} else {
+		m.transfer.statuses[key] = statuses
+	}
+	// 失败时保留上一次的数据，避免频繁重试
+	m.transfer.fetchedAt[key] = localNow()
+	delete(m.transfer.fetching, key)
+	close(done)
+}
+
+// networkStatus 缓存中交易所某币种的充提状态，没有数据时返回nil
+func (m *Monitor) networkStatus(exchange Exchange, asset string) []NetworkStatus {
+	m.transfer.mu.Lock()
+	defer m.transfer.mu.Unlock()
+
+	return m.transfer.statuses[exchange.Name()+"_"+asset]
+}
+
+// findTransferRoute 找到把asset转入目标交易所的最优路线：
+// 目标交易所该链可充值，另一个交易所该链可提币，优先手续费低，其次到账快
+func (m *Monitor) findTransferRoute(to Exchange, asset string) (TransferRoute, bool) {
+	deposits := m.networkStatus(to, asset)
+
+	var best TransferRoute
+	found := false
+	for _, deposit := range deposits {
+		if !deposit.DepositEnabled {
+			continue
+		}
+
+		for _, from := range m.exchanges {
+			if from.Name() == to.Name() {
+				continue
+			}
+			for _, withdraw := range m.networkStatus(from, asset) {
+				if withdraw.Network != deposit.Network || !withdraw.WithdrawEnabled {
+					continue
+				}
+
+				route := TransferRoute{
+					Asset:            asset,
+					Network:          deposit.Network,
+					FromExchange:     from.Name(),
+					Fee:              withdraw.WithdrawFee,
+					EstimatedMinutes: estimateTransferMinutes(deposit),
+				}
+				if !found || betterRoute(route, best) {
+					best = route
+					found = true
+				}
+			}
+		}
+	}
+
+	return best, found
+}
+
+// betterRoute 手续费已知且更低的优先，手续费相同时到账快的优先
+func betterRoute(a, b TransferRoute) bool {
+	if (a.Fee >= 0) != (b.Fee >= 0) {
+		return a.Fee >= 0
+	}
+	if a.Fee != b.Fee {
+		return a.Fee < b.Fee
+	}
+	if a.EstimatedMinutes > 0 && b.EstimatedMinutes > 0 {
+		return a.EstimatedMinutes < b.EstimatedMinutes
+	}
+	return a.EstimatedMinutes > 0
+}
+
+// transferText 某交易所的资金转入说明
+func (m *Monitor) transferText(exchangeName, asset string) string {
+	exchange := m.getExchange(exchangeName)
+	if exchange == nil {
+		return "未知"
+	}
+	if m.transferFetcher(exchange) == nil {
+		if _, ok := exchange.(AuthTransferMarket); ok {
+			return "未配置API凭证"
+		}
+		return "无充提数据"
+	}
+
+	route, ok := m.findTransferRoute(exchange, asset)
+	if !ok {
+		return "无可用网络"
+	}
+
+	text := fmt.Sprintf("%s 经 %s", route.Network, route.FromExchange)
+	if route.EstimatedMinutes > 0 {
+		text += fmt.Sprintf(" 约%.0f分钟", route.EstimatedMinutes)
+	}
+	if route.Fee >= 0 {
+		text += fmt.Sprintf(" 手续费%s %s", formatAmount(route.Fee), asset)
+	}
+	return text
+}
+
+// applyTransfer 为待通知的机会补充两条腿的USDT划转路线，以及基础币种的充值状态
+// 先并发查询各交易所用到的币种的充提状态，最多等待 transferFetchTimeout，之后的路线计算只读取缓存
+func (m *Monitor) applyTransfer(opportunities []ArbitrageOpportunity) {
+	assets := map[string]bool{"USDT": true}
+	for _, opp := range opportunities {
+		assets[strings.TrimSuffix(opp.Symbol, "USDT")] = true
+	}
+
+	var wg sync.WaitGroup
+	for _, exchange := range m.exchanges {
+		for asset := range assets {
+			wg.Add(1)
+			go func(exchange Exchange, asset string) {
+				defer wg.Done()
+				m.refreshNetworkStatus(exchange, asset)
+			}(exchange, asset)
+		}
+	}
+	wg.Wait()
+
+	for i := range opportunities {
+		opp := &opportunities[i]
+		asset := strings.TrimSuffix(opp.Symbol, "USDT")
+
+		opp.HighTransfer = m.transferText(opp.HighRateExchange, "USDT")
+		opp.LowTransfer = m.transferText(opp.LowRateExchange, "USDT")
+		opp.HighAssetTransfer = m.transferText(opp.HighRateExchange, asset)
+		opp.LowAssetTransfer = m.transferText(opp.LowRateExchange, asset)
+	}
+}
+
+// formatAmount 去掉末尾多余的0
+func formatAmount(amount float64) string {
+	text := fmt.Sprintf("%.6f", amount)
+	text = strings.TrimRight(text, "0")
+	return strings.TrimSuffix(text, ".")
+}
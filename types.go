@@ -2,17 +2,211 @@ package main
 
 type ContractData struct {
 	Symbol              string
-	Price               float64
+	Price               float64 // 最新成交价
+	MarkPrice           float64 // 标记价格
+	IndexPrice          float64 // 指数价格
+	BidPrice            float64 // 买一价
+	AskPrice            float64 // 卖一价
 	FundingRate         float64
 	FundingIntervalHour float64 // 结算周期（小时）
 	FundingRate4h       float64 // 转换为4小时的资金费率
 	NextFundingTime     int64
+	FundingTimeRolled   bool    // 下次结算时间是否由过期的缓存值按周期推算得到
+	QuoteVolume24h      float64 // 24h成交额（USDT）
+	OpenInterestUSDT    float64 // 持仓价值（USDT），0表示交易所未提供
+
+	FundingRateCap          float64 // 资金费率上限，0表示未知
+	FundingRateFloor        float64 // 资金费率下限，0表示未知
+	PrevFundingIntervalHour float64 // 最近一次变化前的结算周期，0表示未发生变化
+	IntervalChangedAt       int64   // 最近一次结算周期变化的时间戳（毫秒）
+
+	FundingRatePredicted bool    // 资金费率是否为实时预估值（结算前会持续变化）
+	PrevFundingRate      float64 // 上一期已结算的资金费率
+	PrevFundingTime      int64   // 上一期结算时间戳（毫秒），0表示交易所未提供上一期费率
+}
+
+// AtFundingCap 资金费率是否已触及上限或下限
+func (c *ContractData) AtFundingCap() bool {
+	const epsilon = 1e-9
+	if c.FundingRateCap > 0 && c.FundingRate >= c.FundingRateCap-epsilon {
+		return true
+	}
+	if c.FundingRateFloor < 0 && c.FundingRate <= c.FundingRateFloor+epsilon {
+		return true
+	}
+	return false
+}
+
+// ClampedFundingRate 按上下限截断后的资金费率
+func (c *ContractData) ClampedFundingRate() float64 {
+	return c.ClampRate(c.FundingRate)
+}
+
+// ClampRate 按该合约的资金费率上下限截断，用于预测的费率
+func (c *ContractData) ClampRate(rate float64) float64 {
+	if c.FundingRateCap > 0 && rate > c.FundingRateCap {
+		return c.FundingRateCap
+	}
+	if c.FundingRateFloor < 0 && rate < c.FundingRateFloor {
+		return c.FundingRateFloor
+	}
+	return rate
+}
+
+// PriceFor 按价格来源取价，标记价格或指数价格缺失时回退到最新成交价
+func (c *ContractData) PriceFor(source string) float64 {
+	switch source {
+	case PriceSourceMark:
+		if c.MarkPrice > 0 {
+			return c.MarkPrice
+		}
+	case PriceSourceIndex:
+		if c.IndexPrice > 0 {
+			return c.IndexPrice
+		}
+	}
+	return c.Price
+}
+
+// MidPrice 买一卖一中间价，盘口缺失时回退到指定来源的价格
+func (c *ContractData) MidPrice(source string) float64 {
+	if c.BidPrice > 0 && c.AskPrice > 0 {
+		return (c.BidPrice + c.AskPrice) / 2
+	}
+	return c.PriceFor(source)
+}
+
+// BuyPrice 市价买入的成交价（卖一价），盘口缺失时回退到指定来源的价格
+func (c *ContractData) BuyPrice(source string) float64 {
+	if c.AskPrice > 0 {
+		return c.AskPrice
+	}
+	return c.PriceFor(source)
+}
+
+// SellPrice 市价卖出的成交价（买一价），盘口缺失时回退到指定来源的价格
+func (c *ContractData) SellPrice(source string) float64 {
+	if c.BidPrice > 0 {
+		return c.BidPrice
+	}
+	return c.PriceFor(source)
+}
+
+// Basis 基差：(标记价格 - 指数价格) / 指数价格，缺少数据时为0
+func (c *ContractData) Basis() float64 {
+	if c.MarkPrice <= 0 || c.IndexPrice <= 0 {
+		return 0
+	}
+	return (c.MarkPrice - c.IndexPrice) / c.IndexPrice
+}
+
+// OrderBookLevel 盘口档位，数量统一换算为币的数量
+type OrderBookLevel struct {
+	Price    float64
+	Quantity float64
+}
+
+// OrderBook 订单簿，买盘价格从高到低，卖盘价格从低到高
+type OrderBook struct {
+	Symbol string
+	Bids   []OrderBookLevel
+	Asks   []OrderBookLevel
+}
+
+// FundingRecord 一次已结算的资金费率
+type FundingRecord struct {
+	Exchange    string  `json:"exchange"`
+	Symbol      string  `json:"symbol"`      // 统一格式，如 BTCUSDT
+	FundingTime int64   `json:"fundingTime"` // 结算时间戳（毫秒）
+	FundingRate float64 `json:"fundingRate"`
+}
+
+// SpotTicker 现货行情，Symbol与ContractData相同（如 BTCUSDT）
+type SpotTicker struct {
+	Symbol         string
+	Price          float64 // 最新成交价
+	BidPrice       float64 // 买一价
+	AskPrice       float64 // 卖一价
+	QuoteVolume24h float64 // 24h成交额（USDT）
+}
+
+// SpotMarket 提供USDT现货行情的交易所，用于期现套利分析
+type SpotMarket interface {
+	FetchSpotTickers() (map[string]*SpotTicker, error)
+}
+
+// BorrowRate 全仓杠杆借币利率，用于做空现货的对冲
+type BorrowRate struct {
+	Asset      string  // 币种，如 BTC
+	HourlyRate float64 // 小时利率
+	Borrowable bool    // 当前是否可借
+}
+
+// AuthBorrowMarket 借币利率接口需要签名的交易所，返回 asset -> 利率
+type AuthBorrowMarket interface {
+	FetchAuthBorrowRates(client *AuthClient) (map[string]*BorrowRate, error)
+}
+
+// OpenInterestMarket 行情接口不含持仓量、需要按币种查询持仓量的交易所
+type OpenInterestMarket interface {
+	FetchOpenInterest(symbol string) (float64, error) // 持仓量（币）
+}
+
+// BorrowMarket 公开全仓杠杆借币利率的交易所，返回 asset -> 利率
+type BorrowMarket interface {
+	FetchBorrowRates() (map[string]*BorrowRate, error)
+}
+
+// NetworkStatus 某币种在某条链上的充提状态
+type NetworkStatus struct {
+	Asset           string
+	Network         string // 统一的网络名称，如 TRC20、ERC20
+	DepositEnabled  bool
+	WithdrawEnabled bool
+	WithdrawFee     float64 // 提币手续费（以该币计），-1表示未知
+	Confirmations   int     // 充值到账所需确认数，0表示未知
+}
+
+// TransferMarket 公开充提网络状态的交易所
+type TransferMarket interface {
+	FetchNetworkStatus(asset string) ([]NetworkStatus, error)
+}
+
+// AuthTransferMarket 充提网络状态接口需要签名的交易所
+type AuthTransferMarket interface {
+	FetchAuthNetworkStatus(client *AuthClient, asset string) ([]NetworkStatus, error)
+}
+
+// FeeRate 永续合约的手续费率，负数表示返佣
+type FeeRate struct {
+	Maker float64 // 挂单费率
+	Taker float64 // 吃单费率
+}
+
+// FeeMarket 可通过签名接口查询账户永续合约手续费率的交易所
+type FeeMarket interface {
+	FetchFeeRate(client *AuthClient) (FeeRate, error)
 }
 
 type Exchange interface {
 	Name() string
 	Initialize() error
 	FetchFundingRates() (map[string]*ContractData, error)
-	UpdateFundingIntervals() error // 更新资金费率结算周期
-	UpdateContractStatus() error   // 更新合约状态
+	UpdateFundingIntervals() error                               // 更新资金费率结算周期
+	UpdateContractStatus() error                                 // 更新合约状态
+	FetchOrderBook(symbol string, limit int) (*OrderBook, error) // 按需获取订单簿（统一格式symbol）
+	FundingRatePredicted() bool                                  // 返回的资金费率是否为实时预估值
+	FetchServerTime() (int64, error)                             // 交易所服务器时间（毫秒）
+
+	// FetchFundingHistory 分页获取 [startTime, endTime]（毫秒）内已结算的资金费率
+	FetchFundingHistory(symbol string, startTime, endTime int64) ([]FundingRecord, error)
+
+	// ContractEvents 取出自上次调用以来检测到的合约上线、下架和状态变化
+	ContractEvents() []ContractEvent
+
+	// IntervalChanges 取出自上次调用以来检测到的结算周期变化
+	IntervalChanges() []IntervalChange
+
+	// SchemaChecks 取出自上次调用以来每次获取的必需字段检查结果
+	SchemaChecks() []SchemaCheck
 }
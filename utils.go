@@ -2,6 +2,7 @@ package main
 
 import (
 	"strconv"
+	"strings"
 )
 
 func parseFloat(s string) float64 {
@@ -19,3 +20,43 @@ func parseInt64(s string) int64 {
 	}
 	return i
 }
+
+// toUnderscoreSymbol 统一格式转换为下划线格式 (BTCUSDT -> BTC_USDT)，用于Gate和MEXC
+func toUnderscoreSymbol(symbol string) string {
+	if strings.HasSuffix(symbol, "USDT") {
+		return strings.TrimSuffix(symbol, "USDT") + "_USDT"
+	}
+	return symbol
+}
+
+// toOKXInstID 统一格式转换为OKX永续合约格式 (BTCUSDT -> BTC-USDT-SWAP)
+func toOKXInstID(symbol string) string {
+	return strings.TrimSuffix(symbol, "USDT") + "-USDT-SWAP"
+}
+
+// parseNumber 解析JSON中可能是字符串也可能是数字的数值
+func parseNumber(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case string:
+		return parseFloat(n)
+	}
+	return 0
+}
+
+// parseBookLevels 解析 [[价格, 数量, ...], ...] 格式的盘口，multiplier为每张合约对应的币数量
+func parseBookLevels(raw [][]interface{}, multiplier float64) []OrderBookLevel {
+	levels := make([]OrderBookLevel, 0, len(raw))
+	for _, item := range raw {
+		if len(item) < 2 {
+			continue
+		}
+		price := parseNumber(item[0])
+		quantity := parseNumber(item[1]) * multiplier
+		if price > 0 && quantity > 0 {
+			levels = append(levels, OrderBookLevel{Price: price, Quantity: quantity})
+		}
+	}
+	return levels
+}